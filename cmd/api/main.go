@@ -29,6 +29,11 @@ func main() {
 	// Initialize routes
 	r := InitializeRoutes()
 
+	// Client IPs are only taken from X-Forwarded-For behind our own proxies
+	if err := r.SetTrustedProxies(common.ParseList(env.TrustedProxies)); err != nil {
+		zap.L().Fatal("Invalid trusted proxies", zap.Error(err))
+	}
+
 	zap.L().Info(
 		"Api service is running",
 		zap.String("port", env.ApiPort),
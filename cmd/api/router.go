@@ -4,11 +4,20 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/whitehead421/todo-backend/internal/handlers"
 	"github.com/whitehead421/todo-backend/pkg/middlewares"
+	"github.com/whitehead421/todo-backend/pkg/models"
 )
 
 func InitializeRoutes() *gin.Engine {
 	var todoHandler handlers.TodoHandler = handlers.NewTodoHandler()
+	var shareHandler handlers.ShareHandler = handlers.NewShareHandler()
+	var tagHandler handlers.TagHandler = handlers.NewTagHandler()
+	var checklistHandler handlers.ChecklistHandler = handlers.NewChecklistHandler()
+	var projectHandler handlers.ProjectHandler = handlers.NewProjectHandler()
+	var workflowHandler handlers.WorkflowHandler = handlers.NewWorkflowHandler()
 	var userHandler handlers.UserHandler = handlers.NewUserHandler()
+	var sessionHandler handlers.SessionHandler = handlers.NewSessionHandler()
+	var tokenHandler handlers.TokenHandler = handlers.NewTokenHandler()
+	var organizationHandler handlers.OrganizationHandler = handlers.NewOrganizationHandler()
 
 	gin.SetMode(gin.ReleaseMode)
 
@@ -17,20 +26,94 @@ func InitializeRoutes() *gin.Engine {
 	// Protected todo routes
 	todoRoutes := router.Group("/todo")
 	todoRoutes.Use(middlewares.AuthenticationMiddleware())
+	todoRoutes.Use(middlewares.RequireScopes(models.ScopeTodoRead, models.ScopeTodoWrite))
 	{
 		todoRoutes.POST("/", todoHandler.CreateTodo)
+		todoRoutes.GET("/", todoHandler.ListTodos)
 		todoRoutes.GET("/:id", todoHandler.ReadTodo)
 		todoRoutes.PUT("/:id", todoHandler.UpdateTodo)
+		todoRoutes.PATCH("/:id", todoHandler.PatchTodo)
 		todoRoutes.DELETE("/:id", todoHandler.DeleteTodo)
+		todoRoutes.GET("/:id/shares", shareHandler.ListShares)
+		todoRoutes.PUT("/:id/shares", shareHandler.ShareTodo)
+		todoRoutes.DELETE("/:id/shares/:userId", shareHandler.RevokeShare)
+		todoRoutes.PUT("/:id/tags/:tagId", tagHandler.AttachTag)
+		todoRoutes.DELETE("/:id/tags/:tagId", tagHandler.DetachTag)
+		todoRoutes.GET("/:id/items", checklistHandler.ListItems)
+		todoRoutes.POST("/:id/items", checklistHandler.AddItem)
+		todoRoutes.PUT("/:id/items/order", checklistHandler.ReorderItems)
+		todoRoutes.PUT("/:id/items/:itemId", checklistHandler.UpdateItem)
+		todoRoutes.DELETE("/:id/items/:itemId", checklistHandler.DeleteItem)
+	}
+
+	// Protected tag routes
+	tagRoutes := router.Group("/tags")
+	tagRoutes.Use(middlewares.AuthenticationMiddleware())
+	tagRoutes.Use(middlewares.RequireScopes(models.ScopeTodoRead, models.ScopeTodoWrite))
+	{
+		tagRoutes.POST("/", tagHandler.CreateTag)
+		tagRoutes.GET("/", tagHandler.ListTags)
+		tagRoutes.PUT("/:id", tagHandler.UpdateTag)
+		tagRoutes.DELETE("/:id", tagHandler.DeleteTag)
+	}
+
+	// Protected project routes
+	projectRoutes := router.Group("/projects")
+	projectRoutes.Use(middlewares.AuthenticationMiddleware())
+	projectRoutes.Use(middlewares.RequireScopes(models.ScopeTodoRead, models.ScopeTodoWrite))
+	{
+		projectRoutes.POST("/", projectHandler.CreateProject)
+		projectRoutes.GET("/", projectHandler.ListProjects)
+		projectRoutes.GET("/:id", projectHandler.GetProject)
+		projectRoutes.PUT("/:id", projectHandler.UpdateProject)
+		projectRoutes.DELETE("/:id", projectHandler.DeleteProject)
+		projectRoutes.GET("/:id/workflow", workflowHandler.GetWorkflow)
+		projectRoutes.PUT("/:id/workflow", workflowHandler.SetWorkflow)
+		projectRoutes.DELETE("/:id/workflow", workflowHandler.DeleteWorkflow)
+	}
+
+	// Protected workflow routes
+	workflowRoutes := router.Group("/workflow")
+	workflowRoutes.Use(middlewares.AuthenticationMiddleware())
+	workflowRoutes.Use(middlewares.RequireScopes(models.ScopeTodoRead, models.ScopeTodoWrite))
+	{
+		workflowRoutes.GET("/", workflowHandler.GetWorkflow)
+		workflowRoutes.PUT("/", workflowHandler.SetWorkflow)
+		workflowRoutes.DELETE("/", workflowHandler.DeleteWorkflow)
 	}
 
 	// Protected user routes
 	userRoutes := router.Group("/user")
 	userRoutes.Use(middlewares.AuthenticationMiddleware())
+	// Personal access tokens can't change the account or manage credentials
+	userRoutes.Use(middlewares.RequireScopes(models.ScopeUserRead, ""))
 	{
 		userRoutes.GET("/", userHandler.GetUser)
 		userRoutes.DELETE("/", userHandler.DeleteUser)
 		userRoutes.PUT("/", userHandler.ChangePassword)
+		userRoutes.PUT("/email", userHandler.ChangeEmail)
+		userRoutes.GET("/sessions", sessionHandler.ListSessions)
+		userRoutes.DELETE("/sessions", sessionHandler.RevokeAllSessions)
+		userRoutes.DELETE("/sessions/:id", sessionHandler.RevokeSession)
+		userRoutes.GET("/tokens", tokenHandler.ListTokens)
+		userRoutes.POST("/tokens", tokenHandler.CreateToken)
+		userRoutes.DELETE("/tokens/:id", tokenHandler.RevokeToken)
+	}
+
+	// Protected organization routes
+	organizationRoutes := router.Group("/organizations")
+	organizationRoutes.Use(middlewares.AuthenticationMiddleware())
+	organizationRoutes.Use(middlewares.RequireScopes(models.ScopeUserRead, ""))
+	{
+		organizationRoutes.POST("/", organizationHandler.CreateOrganization)
+		organizationRoutes.GET("/", organizationHandler.ListOrganizations)
+		organizationRoutes.POST("/invitations/accept", organizationHandler.AcceptInvitation)
+		organizationRoutes.GET("/:id", organizationHandler.GetOrganization)
+		organizationRoutes.PUT("/:id", organizationHandler.UpdateOrganization)
+		organizationRoutes.DELETE("/:id", organizationHandler.DeleteOrganization)
+		organizationRoutes.POST("/:id/invitations", organizationHandler.InviteMember)
+		organizationRoutes.PUT("/:id/members/:userId", organizationHandler.UpdateMember)
+		organizationRoutes.DELETE("/:id/members/:userId", organizationHandler.RemoveMember)
 	}
 
 	return router
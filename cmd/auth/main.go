@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -26,14 +28,41 @@ func main() {
 	// Initialize Redis
 	common.InitRedis(env.RedisAddr)
 
+	// Load the keys access tokens are signed with
+	common.InitKeyring(env)
+
 	// Initialize routes
 	r := InitializeRoutes()
 
+	// Client IPs are only taken from X-Forwarded-For behind our own proxies
+	if err := r.SetTrustedProxies(common.ParseList(env.TrustedProxies)); err != nil {
+		zap.L().Fatal("Invalid trusted proxies", zap.Error(err))
+	}
+
+	// Kafka Writer
+	kafkaWriter := common.NewKafkaWriter(env)
+	defer kafkaWriter.Close()
+
+	// Create a context with a cancel function
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Outbox relay
+	outboxInterval, err := time.ParseDuration(env.OutboxInterval)
+	if err != nil {
+		zap.L().Fatal("Invalid outbox interval", zap.Error(err))
+	}
+
+	go common.RunOutboxRelay(ctx, kafkaWriter, outboxInterval)
+
+	// Pick up rotated signing keys
+	go common.RunKeyringRefresh(ctx)
+
 	zap.L().Info(
 		"Auth service is running",
 		zap.String("port", env.AuthPort),
 	)
-	err := r.Run(fmt.Sprintf(":%s", env.AuthPort))
+	err = r.Run(fmt.Sprintf(":%s", env.AuthPort))
 	if err != nil {
 		zap.L().Fatal("Failed to start server", zap.Error(err))
 	}
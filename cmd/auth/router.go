@@ -3,15 +3,11 @@ package main
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/whitehead421/todo-backend/internal/handlers"
-	"github.com/whitehead421/todo-backend/pkg/common"
 	"github.com/whitehead421/todo-backend/pkg/middlewares"
 )
 
 func InitializeRoutes() *gin.Engine {
-	env := common.GetEnvironmentVariables()
-	kafkaWriter := common.NewKafkaWriter(env)
-
-	var authHandler handlers.AuthHandler = handlers.NewAuthHandler(kafkaWriter)
+	var authHandler handlers.AuthHandler = handlers.NewAuthHandler()
 
 	gin.SetMode(gin.ReleaseMode)
 
@@ -19,10 +15,26 @@ func InitializeRoutes() *gin.Engine {
 
 	router.POST("/register", authHandler.Register)
 	router.POST("/login", authHandler.Login)
+	router.POST("/login/mfa", authHandler.VerifyMFA)
+	router.POST("/refresh", authHandler.Refresh)
 	router.POST("/authorize", authHandler.Authorize)
 	router.GET("/verify", authHandler.Verify)
-
-	router.POST("/logout", middlewares.AuthenticationMiddleware(), authHandler.Logout)
+	router.POST("/verify/resend", authHandler.ResendVerification)
+	router.GET("/email/confirm", authHandler.ConfirmEmailChange)
+	router.GET("/unlock", authHandler.Unlock)
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+	router.POST("/forgot-password", authHandler.ForgotPassword)
+	router.POST("/reset-password", authHandler.ResetPassword)
+
+	authenticated := router.Group("/")
+	authenticated.Use(middlewares.AuthenticationMiddleware())
+	authenticated.Use(middlewares.RequireScopes("", ""))
+	{
+		authenticated.POST("/logout", authHandler.Logout)
+		authenticated.POST("/mfa/totp/enroll", authHandler.EnrollTOTP)
+		authenticated.POST("/mfa/totp/enable", authHandler.EnableTOTP)
+		authenticated.POST("/mfa/totp/disable", authHandler.DisableTOTP)
+	}
 
 	return router
 }
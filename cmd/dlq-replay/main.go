@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/whitehead421/todo-backend/pkg/common"
+)
+
+// Moves messages from the dead-letter topic back onto the main topic, e.g.
+// after the cause of the failures was fixed.
+func main() {
+	limit := flag.Int("limit", 0, "maximum number of messages to replay, 0 replays all")
+	idle := flag.Duration("idle", 10*time.Second, "stop when no message arrives within this duration")
+	flag.Parse()
+
+	env := common.GetEnvironmentVariables()
+
+	// Initialize logger
+	logger := common.InitLogger()
+	defer func() {
+		err := logger.Sync() // flushes buffer, if any
+		if err != nil {
+			zap.L().Error("Failed to sync logger", zap.Error(err))
+		}
+	}()
+
+	deadLetterReader := common.NewKafkaDeadLetterReader(env)
+	defer deadLetterReader.Close()
+
+	kafkaWriter := common.NewKafkaWriter(env)
+	defer kafkaWriter.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	replayed, err := common.ReplayDeadLetters(ctx, deadLetterReader, kafkaWriter, *limit, *idle)
+	if err != nil {
+		zap.L().Fatal("Failed to replay dead letters",
+			zap.Int("replayed", replayed),
+			zap.Error(err),
+		)
+	}
+
+	zap.L().Info("Replayed dead letters", zap.Int("replayed", replayed))
+}
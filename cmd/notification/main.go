@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/models"
 )
 
 func main() {
@@ -27,6 +29,9 @@ func main() {
 	// Initialize Redis
 	common.InitRedis(env.RedisAddr)
 
+	// Initialize mailer
+	common.InitMailer(env)
+
 	// Initialize routes
 	r := InitializeRoutes()
 
@@ -34,17 +39,40 @@ func main() {
 	kafkaReader := common.NewKafkaReader(env)
 	defer kafkaReader.Close()
 
+	// Events that keep failing are moved to the dead-letter topic
+	deadLetterWriter := common.NewKafkaDeadLetterWriter(env)
+	defer deadLetterWriter.Close()
+
 	// Create a context with a cancel function
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go common.SendActivationMail(kafkaReader, ctx)
+	// Route events by type
+	dispatcher := common.NewEventDispatcher()
+	dispatcher.Register(models.UserRegistered, common.SendActivationMail)
+	dispatcher.Register(models.VerificationRequested, common.SendActivationMail)
+	dispatcher.Register(models.PasswordResetRequested, common.SendPasswordResetMail)
+	dispatcher.Register(models.AccountLocked, common.SendAccountLockedMail)
+	dispatcher.Register(models.EmailChangeRequested, common.SendEmailChangeNoticeMail)
+	dispatcher.Register(models.EmailChangeConfirming, common.SendEmailConfirmationMail)
+	dispatcher.Register(models.MemberInvited, common.SendInvitationMail)
+	dispatcher.Register(models.TodoReminderDue, common.SendReminderMail)
+
+	go common.ConsumeEvents(kafkaReader, ctx, dispatcher, deadLetterWriter)
+
+	// Reminder scheduler
+	reminderInterval, err := time.ParseDuration(env.ReminderInterval)
+	if err != nil {
+		zap.L().Fatal("Invalid reminder interval", zap.Error(err))
+	}
+
+	go common.RunReminderScheduler(ctx, reminderInterval)
 
 	zap.L().Info(
 		"Notification service is running",
 		zap.String("port", env.NotificationPort),
 	)
-	err := r.Run(fmt.Sprintf(":%s", env.NotificationPort))
+	err = r.Run(fmt.Sprintf(":%s", env.NotificationPort))
 	if err != nil {
 		zap.L().Fatal("Failed to start server", zap.Error(err))
 	}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/whitehead421/todo-backend/pkg/common"
+)
+
+// Rotates the keys access tokens are signed with and retires expired ones.
+// Meant to run on a schedule, e.g. daily from cron with -max-age 720h.
+func main() {
+	maxAge := flag.Duration("max-age", 0, "rotate only when the newest key is older than this, 0 always rotates")
+	flag.Parse()
+
+	env := common.GetEnvironmentVariables()
+
+	// Initialize logger
+	logger := common.InitLogger()
+	defer func() {
+		err := logger.Sync() // flushes buffer, if any
+		if err != nil {
+			zap.L().Error("Failed to sync logger", zap.Error(err))
+		}
+	}()
+
+	// Connect to database
+	common.ConnectDatabase(env.DatabaseDsn)
+
+	retired, err := common.RetireSigningKeys(common.DB)
+	if err != nil {
+		zap.L().Fatal("Failed to retire signing keys", zap.Error(err))
+	}
+	zap.L().Info("Retired expired signing keys", zap.Int64("retired", retired))
+
+	newest, err := common.NewestSigningKey(common.DB)
+	if err != nil {
+		zap.L().Fatal("Failed to find the newest signing key", zap.Error(err))
+	}
+
+	if *maxAge > 0 && newest != nil && time.Since(newest.ActivatesAt) < *maxAge {
+		zap.L().Info("Newest signing key is not old enough to rotate",
+			zap.String("kid", newest.KeyID),
+			zap.Time("activates at", newest.ActivatesAt),
+		)
+		return
+	}
+
+	// Verifiers cache the key set, the new key is published for that long
+	// before it signs. Replaced keys verify until their tokens have expired.
+	publishDelay := common.ParseDuration("JWKS_CACHE_TTL", env.JwksCacheTTL)
+	maxTokenTTL := common.ParseDuration("ACCESS_TOKEN_TTL", env.AccessTokenTTL)
+
+	key, err := common.RotateSigningKeys(common.DB, publishDelay, maxTokenTTL)
+	if err != nil {
+		zap.L().Fatal("Failed to rotate signing keys", zap.Error(err))
+	}
+
+	zap.L().Info("Rotated signing keys",
+		zap.String("kid", key.ID),
+		zap.Time("activates at", key.ActivatesAt),
+	)
+}
@@ -1,19 +1,22 @@
 package handlers
 
 import (
-	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/segmentio/kafka-go"
 	"github.com/whitehead421/todo-backend/pkg/common"
 	"github.com/whitehead421/todo-backend/pkg/entities"
 	"github.com/whitehead421/todo-backend/pkg/models"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type AuthHandler interface {
@@ -22,22 +25,48 @@ type AuthHandler interface {
 	Logout(context *gin.Context)
 	Authorize(context *gin.Context)
 	Verify(context *gin.Context)
+	ResendVerification(context *gin.Context)
+	ForgotPassword(context *gin.Context)
+	ResetPassword(context *gin.Context)
+	Refresh(context *gin.Context)
+	JWKS(context *gin.Context)
+	VerifyMFA(context *gin.Context)
+	EnrollTOTP(context *gin.Context)
+	EnableTOTP(context *gin.Context)
+	DisableTOTP(context *gin.Context)
+	Unlock(context *gin.Context)
+	ConfirmEmailChange(context *gin.Context)
 }
 
+const passwordResetTTL = time.Hour
+
+var (
+	errResetTokenUsed     = errors.New("reset token is already used")
+	errRefreshTokenReused = errors.New("refresh token is already used")
+	errInvalidCode        = errors.New("invalid code")
+)
+
 type authHandler struct {
-	validate    *validator.Validate
-	kafkaWriter KafkaWriter
+	validate        *validator.Validate
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	verifyTokenTTL  time.Duration
+	resendInterval  time.Duration
+	totpIssuer      string
+	loginLimits     common.LoginLimits
 }
 
-type KafkaWriter interface {
-	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
-	Close() error
-}
+func NewAuthHandler() AuthHandler {
+	env := common.GetEnvironmentVariables()
 
-func NewAuthHandler(kafkaWriter KafkaWriter) AuthHandler {
 	return &authHandler{
-		validate:    validator.New(),
-		kafkaWriter: kafkaWriter,
+		validate:        validator.New(),
+		accessTokenTTL:  common.ParseDuration("ACCESS_TOKEN_TTL", env.AccessTokenTTL),
+		refreshTokenTTL: common.ParseDuration("REFRESH_TOKEN_TTL", env.RefreshTokenTTL),
+		verifyTokenTTL:  common.ParseDuration("VERIFY_TOKEN_TTL", env.VerifyTokenTTL),
+		resendInterval:  common.ParseDuration("VERIFY_RESEND_INTERVAL", env.VerifyResendWait),
+		totpIssuer:      env.TotpIssuer,
+		loginLimits:     common.NewLoginLimits(env),
 	}
 }
 
@@ -62,9 +91,17 @@ func (h *authHandler) Register(context *gin.Context) {
 		return
 	}
 
-	var user entities.User
-	result := common.DB.Where("email = ?", registerRequest.Email).First(&user)
-	if result.Error == nil {
+	registered, err := emailRegistered(common.DB, registerRequest.Email, 0)
+	if err != nil {
+		zap.L().Error("Failed to find user",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if registered {
 		zap.L().Error("This email is already registered",
 			zap.String("url path", context.Request.URL.Path),
 		)
@@ -72,37 +109,45 @@ func (h *authHandler) Register(context *gin.Context) {
 		return
 	}
 
-	user = entities.User{
-		Email:       registerRequest.Email,
-		Name:        registerRequest.Name,
-		Password:    common.HashPassword(registerRequest.Password),
-		Verified:    false,
-		VerifyToken: common.GenerateUUID(),
+	verifyTokenExpiresAt := time.Now().Add(h.verifyTokenTTL)
+	user := entities.User{
+		Email:                registerRequest.Email,
+		Name:                 registerRequest.Name,
+		Password:             common.HashPassword(registerRequest.Password),
+		Verified:             false,
+		VerifyToken:          common.GenerateUUID(),
+		VerifyTokenExpiresAt: &verifyTokenExpiresAt,
+		Locale:               registerRequest.Locale,
 	}
 
-	result = common.DB.Create(&user)
-	if result.Error != nil {
-		zap.L().Error("Failed to create user",
-			zap.String("url path", context.Request.URL.Path),
-			zap.Error(result.Error),
-		)
-		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
-		return
+	if user.Locale == "" {
+		user.Locale = common.DefaultLocale
 	}
 
-	err := h.kafkaWriter.WriteMessages(context,
-		kafka.Message{
-			Key:   []byte(user.Email),
-			Value: []byte(user.VerifyToken),
-		},
-	)
+	// The user and its registration event are committed together, the outbox relay publishes the event
+	err = common.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		return common.EnqueueEvent(tx,
+			models.UserRegistered,
+			strconv.FormatUint(user.ID, 10),
+			models.UserRegisteredPayload{
+				UserID:      user.ID,
+				Email:       user.Email,
+				Name:        user.Name,
+				VerifyToken: user.VerifyToken,
+				Locale:      user.Locale,
+			},
+		)
+	})
 	if err != nil {
-		zap.L().Error("Failed to write message to Kafka",
-			zap.Error(err),
+		zap.L().Error("Failed to create user",
 			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
 		)
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email", "message": err.Error()})
-		context.Abort()
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -143,6 +188,10 @@ func (h *authHandler) Login(context *gin.Context) {
 		return
 	}
 
+	if h.loginLocked(context, loginRequest.Email) {
+		return
+	}
+
 	var user entities.User
 	result := common.DB.Where("email = ?", loginRequest.Email).First(&user)
 	if result.Error != nil {
@@ -150,7 +199,8 @@ func (h *authHandler) Login(context *gin.Context) {
 			zap.String("url path", context.Request.URL.Path),
 			zap.Error(result.Error),
 		)
-		context.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		// Unknown emails are counted too, so lockouts don't reveal which accounts exist
+		h.loginFailed(context, loginRequest.Email, nil, "Invalid credentials")
 		return
 	}
 
@@ -158,7 +208,7 @@ func (h *authHandler) Login(context *gin.Context) {
 		zap.L().Error("Invalid password",
 			zap.String("url path", context.Request.URL.Path),
 		)
-		context.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		h.loginFailed(context, loginRequest.Email, &user, "Invalid credentials")
 		return
 	}
 
@@ -170,19 +220,29 @@ func (h *authHandler) Login(context *gin.Context) {
 		return
 	}
 
-	token, err := common.CreateToken(user.ID)
-	if err != nil {
-		zap.L().Error("Failed to create token",
-			zap.String("url path", context.Request.URL.Path),
-			zap.Error(err),
-		)
-		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// With two-factor authentication the session is created by VerifyMFA
+	if user.TotpEnabled {
+		mfaToken, err := common.CreateMFAChallenge(context, user.ID, mfaChallengeTTL)
+		if err != nil {
+			zap.L().Error("Failed to create mfa challenge",
+				zap.String("url path", context.Request.URL.Path),
+				zap.Error(err),
+			)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		context.JSON(http.StatusOK, models.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresIn:   int64(mfaChallengeTTL.Seconds()),
+		})
 		return
 	}
 
-	err = common.RedisClient.Set(context, token, "token", time.Hour).Err()
+	loginResponse, err := h.startSession(context, user.ID)
 	if err != nil {
-		zap.L().Error("Failed to set token to redis",
+		zap.L().Error("Failed to create session",
 			zap.String("url path", context.Request.URL.Path),
 			zap.Error(err),
 		)
@@ -190,10 +250,7 @@ func (h *authHandler) Login(context *gin.Context) {
 		return
 	}
 
-	loginResponse := models.LoginResponse{
-		Token:  token,
-		UserId: user.ID,
-	}
+	h.loginSucceeded(context, user.Email)
 
 	zap.L().Info("User logged in successfully",
 		zap.Uint64("user ID", user.ID),
@@ -222,7 +279,7 @@ func (h *authHandler) Logout(context *gin.Context) {
 		return
 	}
 
-	err := common.RedisClient.Del(context, tokenString).Err()
+	err := common.RemoveSession(context, userID.(uint64), tokenString)
 	if err != nil {
 		zap.L().Error("Failed to delete token from redis",
 			zap.Error(err),
@@ -233,6 +290,20 @@ func (h *authHandler) Logout(context *gin.Context) {
 		return
 	}
 
+	// Revoking the session makes its refresh token useless as well
+	if sessionID := context.GetString("sessionID"); sessionID != "" {
+		_, err = common.RevokeSession(context, common.DB, userID.(uint64), sessionID)
+		if err != nil {
+			zap.L().Error("Failed to revoke session",
+				zap.String("url path", context.Request.URL.Path),
+				zap.Error(err),
+			)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			context.Abort()
+			return
+		}
+	}
+
 	zap.L().Info("User logged out successfully",
 		zap.Uint64("user ID", userID.(uint64)),
 		zap.String("url path", context.Request.URL.Path),
@@ -258,7 +329,12 @@ func (h *authHandler) Authorize(context *gin.Context) {
 		return
 	}
 
-	id, err := common.ValidateToken(tokenString)
+	if common.IsPersonalAccessToken(tokenString) {
+		h.authorizePersonalAccessToken(context, tokenString)
+		return
+	}
+
+	claims, err := common.ValidateToken(tokenString)
 	if err != nil {
 		zap.L().Error("Token is not valid anymore.", zap.Error(err))
 		context.JSON(http.StatusUnauthorized, gin.H{"error": "Token is not valid anymore."})
@@ -275,7 +351,7 @@ func (h *authHandler) Authorize(context *gin.Context) {
 	}
 
 	var user entities.User
-	result := common.DB.First(&user, id)
+	result := common.DB.First(&user, claims.UserID)
 	if result.Error != nil {
 		zap.L().Error("User not found for authentication middleware", zap.Error(result.Error))
 		context.JSON(http.StatusUnauthorized, gin.H{"error": "Token is not valid anymore or user does not exist"})
@@ -283,7 +359,59 @@ func (h *authHandler) Authorize(context *gin.Context) {
 		return
 	}
 
-	context.JSON(http.StatusOK, gin.H{"message": "Authorized", "user_id": id})
+	var session entities.Session
+	result = common.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", claims.SessionID, claims.UserID).
+		First(&session)
+	if result.Error != nil {
+		zap.L().Error("Session is revoked or not found", zap.Error(result.Error))
+		context.JSON(http.StatusUnauthorized, gin.H{"error": "Token is not valid anymore"})
+		context.Abort()
+		return
+	}
+
+	// The request is authorized anyway, a stale last seen time is not worth failing it
+	err = common.TouchSession(common.DB, session.ID, context.ClientIP(), context.Request.UserAgent())
+	if err != nil {
+		zap.L().Error("Failed to update session", zap.Error(err))
+	}
+
+	context.JSON(http.StatusOK, models.AuthorizeResponse{
+		Message:   "Authorized",
+		UserId:    claims.UserID,
+		SessionId: claims.SessionID,
+	})
+}
+
+// authorizePersonalAccessToken authorizes a personal access token. When the
+// scope query parameter is set, the token must have that scope.
+func (h *authHandler) authorizePersonalAccessToken(context *gin.Context, token string) {
+	pat, err := common.AuthenticatePersonalAccessToken(common.DB, token)
+	if errors.Is(err, common.ErrInvalidPersonalAccessToken) {
+		zap.L().Error("Personal access token is not valid", zap.Error(err))
+		context.JSON(http.StatusUnauthorized, gin.H{"error": "Token is not valid anymore"})
+		context.Abort()
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to authenticate personal access token", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		context.Abort()
+		return
+	}
+
+	scopes := common.TokenScopes(pat)
+	if scope := context.Query("scope"); scope != "" && !slices.Contains(scopes, scope) {
+		zap.L().Error("Personal access token is missing scope", zap.String("scope", scope))
+		context.JSON(http.StatusForbidden, gin.H{"error": "Insufficient scope"})
+		context.Abort()
+		return
+	}
+
+	context.JSON(http.StatusOK, models.AuthorizeResponse{
+		Message: "Authorized",
+		UserId:  pat.UserID,
+		Scopes:  scopes,
+	})
 }
 
 func (h *authHandler) Verify(context *gin.Context) {
@@ -307,8 +435,23 @@ func (h *authHandler) Verify(context *gin.Context) {
 		return
 	}
 
-	user.Verified = true
-	result = common.DB.Save(&user)
+	if user.VerifyTokenExpiresAt == nil || user.VerifyTokenExpiresAt.Before(time.Now()) {
+		zap.L().Error("Verify token is expired",
+			zap.Uint64("user ID", user.ID),
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Verify token is expired, request a new one"})
+		return
+	}
+
+	// Clearing the token makes it single use, also for concurrent requests
+	result = common.DB.Model(&entities.User{}).
+		Where("id = ? AND verify_token = ?", user.ID, verifyToken).
+		Updates(map[string]interface{}{
+			"verified":                true,
+			"verify_token":            "",
+			"verify_token_expires_at": nil,
+		})
 	if result.Error != nil {
 		zap.L().Error("Failed to update user",
 			zap.Error(result.Error),
@@ -318,6 +461,15 @@ func (h *authHandler) Verify(context *gin.Context) {
 		return
 	}
 
+	if result.RowsAffected == 0 {
+		zap.L().Error("Verify token is already used",
+			zap.Uint64("user ID", user.ID),
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
 	zap.L().Info("User verified successfully",
 		zap.Uint64("user ID", user.ID),
 		zap.String("url path", context.Request.URL.Path),
@@ -325,3 +477,505 @@ func (h *authHandler) Verify(context *gin.Context) {
 
 	context.JSON(http.StatusOK, gin.H{"message": "Account verified successfully"})
 }
+
+func (h *authHandler) ResendVerification(context *gin.Context) {
+	var request models.ResendVerificationRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Throttled by address before the lookup, so unknown emails are throttled alike
+	wait, err := common.ReserveVerificationResend(context, request.Email, h.resendInterval)
+	if err != nil {
+		zap.L().Error("Failed to throttle verification email",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if wait > 0 {
+		zap.L().Error("Verification email was sent recently",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		context.JSON(http.StatusTooManyRequests, gin.H{"error": "A verification email was sent recently, try again later"})
+		return
+	}
+
+	// Unknown and verified emails get the same answer, so this endpoint can't be used to find accounts
+	response := gin.H{"message": "If the email is registered and not verified yet, a verification link has been sent"}
+
+	var user entities.User
+	result := common.DB.Where("email = ?", request.Email).First(&user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			zap.L().Info("Verification requested for unknown email",
+				zap.String("url path", context.Request.URL.Path),
+			)
+			context.JSON(http.StatusOK, response)
+			return
+		}
+
+		zap.L().Error("Failed to find user",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if user.Verified {
+		zap.L().Info("Verification requested for verified user",
+			zap.Uint64("user ID", user.ID),
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusOK, response)
+		return
+	}
+
+	// A new token replaces the old one, only the latest link works
+	verifyToken := common.GenerateUUID()
+	verifyTokenExpiresAt := time.Now().Add(h.verifyTokenTTL)
+
+	err = common.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&user).Updates(map[string]interface{}{
+			"verify_token":            verifyToken,
+			"verify_token_expires_at": verifyTokenExpiresAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return common.EnqueueEvent(tx,
+			models.VerificationRequested,
+			strconv.FormatUint(user.ID, 10),
+			models.UserRegisteredPayload{
+				UserID:      user.ID,
+				Email:       user.Email,
+				Name:        user.Name,
+				VerifyToken: verifyToken,
+				Locale:      user.Locale,
+			},
+		)
+	})
+	if err != nil {
+		zap.L().Error("Failed to create verify token",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Verification email requested",
+		zap.Uint64("user ID", user.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, response)
+}
+
+func (h *authHandler) ForgotPassword(context *gin.Context) {
+	var request models.ForgotPasswordRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Unknown emails get the same answer, so this endpoint can't be used to find accounts
+	response := gin.H{"message": "If the email is registered, a password reset link has been sent"}
+
+	var user entities.User
+	result := common.DB.Where("email = ?", request.Email).First(&user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			zap.L().Info("Password reset requested for unknown email",
+				zap.String("url path", context.Request.URL.Path),
+			)
+			context.JSON(http.StatusOK, response)
+			return
+		}
+
+		zap.L().Error("Failed to find user",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	token, err := common.GenerateSecureToken()
+	if err != nil {
+		zap.L().Error("Failed to generate reset token",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resetToken := entities.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: common.HashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+
+	err = common.DB.Transaction(func(tx *gorm.DB) error {
+		// Only the most recent reset link stays valid
+		err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).
+			Delete(&entities.PasswordResetToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(&resetToken).Error; err != nil {
+			return err
+		}
+
+		return common.EnqueueEvent(tx,
+			models.PasswordResetRequested,
+			strconv.FormatUint(user.ID, 10),
+			models.PasswordResetRequestedPayload{
+				UserID:    user.ID,
+				Email:     user.Email,
+				Name:      user.Name,
+				Locale:    user.Locale,
+				Token:     token,
+				ExpiresAt: resetToken.ExpiresAt,
+			},
+		)
+	})
+	if err != nil {
+		zap.L().Error("Failed to create reset token",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Password reset requested",
+		zap.Uint64("user ID", user.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, response)
+}
+
+func (h *authHandler) ResetPassword(context *gin.Context) {
+	var request models.ResetPasswordRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var resetToken entities.PasswordResetToken
+	result := common.DB.Where("token_hash = ?", common.HashToken(request.Token)).First(&resetToken)
+	if result.Error != nil || resetToken.UsedAt != nil || resetToken.ExpiresAt.Before(time.Now()) {
+		zap.L().Error("Invalid reset token",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Reset token is invalid or expired"})
+		return
+	}
+
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		// Claim the token first, so two concurrent requests can't both use it
+		result := tx.Model(&resetToken).Where("used_at IS NULL").Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errResetTokenUsed
+		}
+
+		err := tx.Model(&entities.User{}).
+			Where("id = ?", resetToken.UserID).
+			Update("password", common.HashPassword(request.Password)).Error
+		if err != nil {
+			return err
+		}
+
+		// Log out everywhere, the old password may have been compromised
+		return common.RevokeAllSessions(context, tx, resetToken.UserID)
+	})
+	if errors.Is(err, errResetTokenUsed) {
+		zap.L().Error("Reset token is already used",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Reset token is invalid or expired"})
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to reset password",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Whoever can reset the password can also unlock the account
+	var user entities.User
+	if err := common.DB.First(&user, resetToken.UserID).Error; err == nil {
+		err = common.ClearLoginFailures(context, user.Email)
+		if err != nil {
+			zap.L().Error("Failed to clear failed logins",
+				zap.String("url path", context.Request.URL.Path),
+				zap.Error(err),
+			)
+		}
+	}
+
+	zap.L().Info("Password reset successfully",
+		zap.Uint64("user ID", resetToken.UserID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Your password has been reset"})
+}
+
+func (h *authHandler) Refresh(context *gin.Context) {
+	var request models.RefreshRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var refreshToken entities.RefreshToken
+	result := common.DB.Where("token_hash = ?", common.HashToken(request.RefreshToken)).First(&refreshToken)
+	if result.Error != nil {
+		zap.L().Error("Failed to find refresh token",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token is invalid or expired"})
+		return
+	}
+
+	var session entities.Session
+	result = common.DB.Where("id = ?", refreshToken.SessionID).First(&session)
+	if result.Error != nil || session.RevokedAt != nil || session.ExpiresAt.Before(time.Now()) {
+		zap.L().Error("Session is revoked or expired",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token is invalid or expired"})
+		return
+	}
+
+	if refreshToken.UsedAt != nil {
+		h.revokeReusedSession(context, session)
+		return
+	}
+
+	if refreshToken.ExpiresAt.Before(time.Now()) {
+		zap.L().Error("Refresh token is expired",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token is invalid or expired"})
+		return
+	}
+
+	var response models.LoginResponse
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		// Claim the token first, a concurrent request with the same token is a replay
+		result := tx.Model(&refreshToken).Where("used_at IS NULL").Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRefreshTokenReused
+		}
+
+		var err error
+		response, err = h.issueTokens(context, tx, &session)
+		if err != nil {
+			return err
+		}
+
+		return common.TouchSession(tx, session.ID, context.ClientIP(), context.Request.UserAgent())
+	})
+	if errors.Is(err, errRefreshTokenReused) {
+		h.revokeReusedSession(context, session)
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to refresh tokens",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Tokens refreshed successfully",
+		zap.Uint64("user ID", session.UserID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, response)
+}
+
+func (h *authHandler) JWKS(context *gin.Context) {
+	keySet, err := common.PublicKeySet()
+	if err != nil {
+		zap.L().Error("Failed to build key set",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Let clients cache the keys, rotated keys are published before they are used
+	context.Header("Cache-Control", "public, max-age=300")
+	context.JSON(http.StatusOK, keySet)
+}
+
+// startSession creates a session for a user that passed authentication.
+func (h *authHandler) startSession(context *gin.Context, userID uint64) (models.LoginResponse, error) {
+	session := entities.Session{
+		ID:         common.GenerateUUID(),
+		UserID:     userID,
+		UserAgent:  context.Request.UserAgent(),
+		IPAddress:  context.ClientIP(),
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(h.refreshTokenTTL),
+	}
+
+	var response models.LoginResponse
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		response, err = h.issueTokens(context, tx, &session)
+		return err
+	})
+
+	return response, err
+}
+
+// issueTokens rotates the tokens of a session. It stores a new refresh token,
+// extends the session and creates an access token bound to it.
+func (h *authHandler) issueTokens(context *gin.Context, tx *gorm.DB, session *entities.Session) (models.LoginResponse, error) {
+	refreshToken, err := common.GenerateSecureToken()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	expiresAt := time.Now().Add(h.refreshTokenTTL)
+	err = tx.Create(&entities.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: common.HashToken(refreshToken),
+		ExpiresAt: expiresAt,
+	}).Error
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	err = tx.Model(session).Update("expires_at", expiresAt).Error
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	token, err := common.CreateToken(session.UserID, session.ID, h.accessTokenTTL)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	err = common.StoreSession(context, session.UserID, token, h.accessTokenTTL)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return models.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.accessTokenTTL.Seconds()),
+		UserId:       session.UserID,
+	}, nil
+}
+
+// revokeReusedSession handles a replayed refresh token. The token may have
+// been stolen, so the whole session is revoked.
+func (h *authHandler) revokeReusedSession(context *gin.Context, session entities.Session) {
+	zap.L().Warn("Refresh token reuse detected, revoking session",
+		zap.Uint64("user ID", session.UserID),
+		zap.String("session ID", session.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	if _, err := common.RevokeSession(context, common.DB, session.UserID, session.ID); err != nil {
+		zap.L().Error("Failed to revoke session",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token is invalid or expired"})
+}
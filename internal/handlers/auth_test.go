@@ -2,6 +2,8 @@ package handlers_test
 
 import (
 	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -11,8 +13,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redismock/v9"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 	"github.com/whitehead421/todo-backend/internal/handlers"
 	"github.com/whitehead421/todo-backend/mocks"
 	"github.com/whitehead421/todo-backend/pkg/common"
@@ -23,8 +25,7 @@ import (
 func TestRegister(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	mockKafkaWriter := &mocks.KafkaWriter{}
-	authHandler := handlers.NewAuthHandler(mockKafkaWriter)
+	authHandler := handlers.NewAuthHandler()
 
 	router := gin.Default()
 	router.POST("/register", authHandler.Register)
@@ -36,13 +37,14 @@ func TestRegister(t *testing.T) {
 	tests := []struct {
 		name           string
 		requestBody    interface{}
-		mockBehavior   func(mockAuthHandler *mocks.AuthHandler, mockKafkaWriter *mocks.KafkaWriter)
+		mockBehavior   func(mockAuthHandler *mocks.AuthHandler)
 		expectedStatus int
+		expectedEvents int64
 	}{
 		{
 			name:           "Failed to bind JSON",
 			requestBody:    "invalid json",
-			mockBehavior:   func(mockAuthHandler *mocks.AuthHandler, mockKafkaWriter *mocks.KafkaWriter) {},
+			mockBehavior:   func(mockAuthHandler *mocks.AuthHandler) {},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
@@ -52,7 +54,19 @@ func TestRegister(t *testing.T) {
 				Name:     "",
 				Password: "",
 			},
-			mockBehavior:   func(mockAuthHandler *mocks.AuthHandler, mockKafkaWriter *mocks.KafkaWriter) {},
+			mockBehavior:   func(mockAuthHandler *mocks.AuthHandler) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid locale",
+			requestBody: models.RegisterRequest{
+				Email:    "locale@example.com",
+				Name:     "Test User",
+				Password: "password",
+				Confirm:  "password",
+				Locale:   "not a locale",
+			},
+			mockBehavior:   func(mockAuthHandler *mocks.AuthHandler) {},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
@@ -63,7 +77,7 @@ func TestRegister(t *testing.T) {
 				Password: "password",
 				Confirm:  "password",
 			},
-			mockBehavior: func(mockAuthHandler *mocks.AuthHandler, mockKafkaWriter *mocks.KafkaWriter) {
+			mockBehavior: func(mockAuthHandler *mocks.AuthHandler) {
 				common.DB.Create(&entities.User{
 					Email:    "registered@example.com",
 					Name:     "Test User",
@@ -80,10 +94,9 @@ func TestRegister(t *testing.T) {
 				Password: "password",
 				Confirm:  "password",
 			},
-			mockBehavior: func(mockAuthHandler *mocks.AuthHandler, mockKafkaWriter *mocks.KafkaWriter) {
-				mockKafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).Return(nil)
-			},
+			mockBehavior:   func(mockAuthHandler *mocks.AuthHandler) {},
 			expectedStatus: http.StatusCreated,
+			expectedEvents: 1,
 		},
 	}
 
@@ -91,7 +104,13 @@ func TestRegister(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup mock handler
 			mockAuthHandler := new(mocks.AuthHandler)
-			tt.mockBehavior(mockAuthHandler, mockKafkaWriter)
+			tt.mockBehavior(mockAuthHandler)
+
+			// Registration events go through the outbox instead of straight to Kafka
+			var eventsBefore int64
+			common.DB.Model(&entities.OutboxEvent{}).
+				Where("event_type = ?", models.UserRegistered).
+				Count(&eventsBefore)
 
 			// Create request body
 			var reqBody []byte
@@ -113,6 +132,12 @@ func TestRegister(t *testing.T) {
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			mockAuthHandler.AssertExpectations(t)
+
+			var eventsAfter int64
+			common.DB.Model(&entities.OutboxEvent{}).
+				Where("event_type = ?", models.UserRegistered).
+				Count(&eventsAfter)
+			assert.Equal(t, tt.expectedEvents, eventsAfter-eventsBefore)
 		})
 	}
 }
@@ -120,8 +145,7 @@ func TestRegister(t *testing.T) {
 func TestLogin(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	mockKafkaWriter := &mocks.KafkaWriter{}
-	authHandler := handlers.NewAuthHandler(mockKafkaWriter)
+	authHandler := handlers.NewAuthHandler()
 
 	router := gin.Default()
 	router.POST("/login", authHandler.Login)
@@ -248,8 +272,7 @@ func TestLogin(t *testing.T) {
 func TestLogout(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	mockKafkaWriter := &mocks.KafkaWriter{}
-	authHandler := handlers.NewAuthHandler(mockKafkaWriter)
+	authHandler := handlers.NewAuthHandler()
 
 	router := gin.Default()
 	router.POST("/logout", func(c *gin.Context) {
@@ -271,6 +294,7 @@ func TestLogout(t *testing.T) {
 			name: "Successful logout",
 			mockBehavior: func(mockRedis redismock.ClientMock) {
 				mockRedis.ExpectDel("token").SetVal(1)
+				mockRedis.ExpectSRem("user_sessions:1", "token").SetVal(1)
 			},
 			expectedStatus: http.StatusOK,
 			Header: map[string]string{
@@ -333,8 +357,7 @@ func TestLogout(t *testing.T) {
 func TestVerify(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	mockKafkaWriter := &mocks.KafkaWriter{}
-	authHandler := handlers.NewAuthHandler(mockKafkaWriter)
+	authHandler := handlers.NewAuthHandler()
 
 	router := gin.Default()
 	router.GET("/verify", authHandler.Verify)
@@ -344,6 +367,9 @@ func TestVerify(t *testing.T) {
 	common.SetDB(testDB) // Set the mock database for testing
 
 	uuidToken := common.GenerateUUID()
+	expiredToken := common.GenerateUUID()
+	expiresAt := time.Now().Add(time.Hour)
+	expiredAt := time.Now().Add(-time.Hour)
 
 	tests := []struct {
 		name           string
@@ -355,11 +381,12 @@ func TestVerify(t *testing.T) {
 			name: "Successful verification",
 			mockBehavior: func(mockAuthHandler *mocks.AuthHandler) {
 				common.DB.Create(&entities.User{
-					Email:       "test@example.com",
-					Name:        "Test User",
-					Password:    common.HashPassword("password"),
-					Verified:    false,
-					VerifyToken: uuidToken,
+					Email:                "test@example.com",
+					Name:                 "Test User",
+					Password:             common.HashPassword("password"),
+					Verified:             false,
+					VerifyToken:          uuidToken,
+					VerifyTokenExpiresAt: &expiresAt,
 				})
 			},
 			expectedStatus: http.StatusOK,
@@ -367,6 +394,31 @@ func TestVerify(t *testing.T) {
 				"token": uuidToken,
 			},
 		},
+		{
+			name:           "Token is already used",
+			mockBehavior:   func(mockAuthHandler *mocks.AuthHandler) {},
+			expectedStatus: http.StatusNotFound,
+			queryParams: map[string]string{
+				"token": uuidToken,
+			},
+		},
+		{
+			name: "Token is expired",
+			mockBehavior: func(mockAuthHandler *mocks.AuthHandler) {
+				common.DB.Create(&entities.User{
+					Email:                "expired@example.com",
+					Name:                 "Expired User",
+					Password:             common.HashPassword("password"),
+					Verified:             false,
+					VerifyToken:          expiredToken,
+					VerifyTokenExpiresAt: &expiredAt,
+				})
+			},
+			expectedStatus: http.StatusBadRequest,
+			queryParams: map[string]string{
+				"token": expiredToken,
+			},
+		},
 		{
 			name:           "Verification token not found",
 			mockBehavior:   func(mockAuthHandler *mocks.AuthHandler) {},
@@ -404,23 +456,168 @@ func TestVerify(t *testing.T) {
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			mockAuthHandler.AssertExpectations(t)
+
+			if tt.expectedStatus == http.StatusOK {
+				var user entities.User
+				common.DB.First(&user, "email = ?", "test@example.com")
+				assert.True(t, user.Verified)
+				assert.Empty(t, user.VerifyToken)
+				assert.Nil(t, user.VerifyTokenExpiresAt)
+			}
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.POST("/verify/resend", authHandler.ResendVerification)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	// Mock Redis setup
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+
+	oldToken := common.GenerateUUID()
+	expiredAt := time.Now().Add(-time.Hour)
+	common.DB.Create(&entities.User{
+		Email:                "pending@example.com",
+		Name:                 "Pending User",
+		VerifyToken:          oldToken,
+		VerifyTokenExpiresAt: &expiredAt,
+	})
+	common.DB.Create(&entities.User{
+		Email:    "verified@example.com",
+		Name:     "Verified User",
+		Verified: true,
+	})
+
+	tests := []struct {
+		name           string
+		email          string
+		mockBehavior   func()
+		expectedStatus int
+		expectedEvents int64
+	}{
+		{
+			name:           "Validation error",
+			email:          "invalid-email",
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Unknown email",
+			email: "unknown@example.com",
+			mockBehavior: func() {
+				mockRedis.ExpectSetNX("verify_resend:unknown@example.com", 1, time.Minute).SetVal(true)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Already verified",
+			email: "verified@example.com",
+			mockBehavior: func() {
+				mockRedis.ExpectSetNX("verify_resend:verified@example.com", 1, time.Minute).SetVal(true)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Successful resend",
+			email: "pending@example.com",
+			mockBehavior: func() {
+				mockRedis.ExpectSetNX("verify_resend:pending@example.com", 1, time.Minute).SetVal(true)
+			},
+			expectedStatus: http.StatusOK,
+			expectedEvents: 1,
+		},
+		{
+			name:  "Sent recently",
+			email: "pending@example.com",
+			mockBehavior: func() {
+				mockRedis.ExpectSetNX("verify_resend:pending@example.com", 1, time.Minute).SetVal(false)
+				mockRedis.ExpectTTL("verify_resend:pending@example.com").SetVal(30 * time.Second)
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedEvents: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior()
+
+			reqBody, err := json.Marshal(models.ResendVerificationRequest{Email: tt.email})
+			assert.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/verify/resend", bytes.NewBuffer(reqBody))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusTooManyRequests {
+				assert.Equal(t, "30", w.Header().Get("Retry-After"))
+			}
+
+			var events int64
+			common.DB.Model(&entities.OutboxEvent{}).
+				Where("event_type = ?", models.VerificationRequested).
+				Count(&events)
+			assert.Equal(t, tt.expectedEvents, events)
+
+			if err := mockRedis.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
 		})
 	}
+
+	// The new token replaces the expired one
+	var user entities.User
+	common.DB.First(&user, "email = ?", "pending@example.com")
+	assert.NotEqual(t, oldToken, user.VerifyToken)
+	if assert.NotNil(t, user.VerifyTokenExpiresAt) {
+		assert.True(t, user.VerifyTokenExpiresAt.After(time.Now()))
+	}
 }
 
 func TestAuthorize(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	mockKafkaWriter := &mocks.KafkaWriter{}
-	authHandler := handlers.NewAuthHandler(mockKafkaWriter)
+	authHandler := handlers.NewAuthHandler()
 
 	router := gin.Default()
 	router.POST("/authorize", authHandler.Authorize)
 
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
 	// Mock Redis setup
 	db, mockRedis := redismock.NewClientMock()
 	common.SetRedisClient(db)
 
+	signingKey, err := common.GenerateSigningKey()
+	assert.NoError(t, err)
+	common.SetKeyring(common.NewKeyring(signingKey))
+
+	user := entities.User{Email: "authorize@example.com", Name: "Authorize", Verified: true}
+	common.DB.Create(&user)
+
+	revokedAt := time.Now()
+	common.DB.Create(&entities.Session{ID: "active", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	common.DB.Create(&entities.Session{ID: "revoked", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt})
+
+	activeToken, _ := common.CreateToken(user.ID, "active", time.Minute)
+	revokedToken, _ := common.CreateToken(user.ID, "revoked", time.Minute)
+
 	tests := []struct {
 		name           string
 		mockBehavior   func(mockRedis redismock.ClientMock)
@@ -451,11 +648,32 @@ func TestAuthorize(t *testing.T) {
 				"Authorization": "Bearer token",
 			},
 		},
+		{
+			name: "Session is revoked",
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				mockRedis.ExpectGet(revokedToken).SetVal("token")
+			},
+			expectedStatus: http.StatusUnauthorized,
+			Header: map[string]string{
+				"Authorization": "Bearer " + revokedToken,
+			},
+		},
+		{
+			name: "Successfully authorized",
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				mockRedis.ExpectGet(activeToken).SetVal("token")
+			},
+			expectedStatus: http.StatusOK,
+			Header: map[string]string{
+				"Authorization": "Bearer " + activeToken,
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup mock Redis client
+			mockRedis.ClearExpect()
 			tt.mockBehavior(mockRedis)
 
 			req, err := http.NewRequest(http.MethodPost, "/authorize", nil)
@@ -474,3 +692,607 @@ func TestAuthorize(t *testing.T) {
 		})
 	}
 }
+
+func TestForgotPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.POST("/forgot-password", authHandler.ForgotPassword)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.User{
+		ID:       1,
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: "password",
+		Verified: true,
+	})
+
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		expectedStatus int
+		expectedTokens int64
+	}{
+		{
+			name:           "Failed to bind JSON",
+			requestBody:    "invalid json",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Validation error",
+			requestBody:    models.ForgotPasswordRequest{Email: "invalid-email"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unknown email",
+			requestBody:    models.ForgotPasswordRequest{Email: "unknown@example.com"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Reset requested",
+			requestBody:    models.ForgotPasswordRequest{Email: "test@example.com"},
+			expectedStatus: http.StatusOK,
+			expectedTokens: 1,
+		},
+		{
+			name:           "Reset requested again",
+			requestBody:    models.ForgotPasswordRequest{Email: "test@example.com"},
+			expectedStatus: http.StatusOK,
+			expectedTokens: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create request body
+			var reqBody []byte
+			var err error
+			if body, ok := tt.requestBody.(models.ForgotPasswordRequest); ok {
+				reqBody, err = json.Marshal(body)
+				assert.NoError(t, err)
+			} else {
+				reqBody = []byte(tt.requestBody.(string))
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/forgot-password", bytes.NewBuffer(reqBody))
+			assert.NoError(t, err)
+
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			// Only the latest token is kept
+			var tokens int64
+			common.DB.Model(&entities.PasswordResetToken{}).Where("user_id = ?", 1).Count(&tokens)
+			assert.Equal(t, tt.expectedTokens, tokens)
+		})
+	}
+
+	var events int64
+	common.DB.Model(&entities.OutboxEvent{}).
+		Where("event_type = ?", models.PasswordResetRequested).
+		Count(&events)
+	assert.Equal(t, int64(2), events)
+}
+
+func TestResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.POST("/reset-password", authHandler.ResetPassword)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	// Mock Redis setup
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+
+	common.DB.Create(&entities.User{
+		ID:       1,
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: "password",
+		Verified: true,
+	})
+	common.DB.Create(&entities.PasswordResetToken{
+		UserID:    1,
+		TokenHash: common.HashToken("valid-token"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	common.DB.Create(&entities.PasswordResetToken{
+		UserID:    1,
+		TokenHash: common.HashToken("expired-token"),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		mockBehavior   func(mockRedis redismock.ClientMock)
+		expectedStatus int
+	}{
+		{
+			name:           "Failed to bind JSON",
+			requestBody:    "invalid json",
+			mockBehavior:   func(mockRedis redismock.ClientMock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Passwords do not match",
+			requestBody: models.ResetPasswordRequest{
+				Token:    "valid-token",
+				Password: "new-password",
+				Confirm:  "other-password",
+			},
+			mockBehavior:   func(mockRedis redismock.ClientMock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Unknown token",
+			requestBody: models.ResetPasswordRequest{
+				Token:    "unknown-token",
+				Password: "new-password",
+				Confirm:  "new-password",
+			},
+			mockBehavior:   func(mockRedis redismock.ClientMock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Expired token",
+			requestBody: models.ResetPasswordRequest{
+				Token:    "expired-token",
+				Password: "new-password",
+				Confirm:  "new-password",
+			},
+			mockBehavior:   func(mockRedis redismock.ClientMock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Successful reset",
+			requestBody: models.ResetPasswordRequest{
+				Token:    "valid-token",
+				Password: "new-password",
+				Confirm:  "new-password",
+			},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSMembers("user_sessions:1").SetVal([]string{"token"})
+				mockRedis.ExpectDel("token", "user_sessions:1").SetVal(2)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Token already used",
+			requestBody: models.ResetPasswordRequest{
+				Token:    "valid-token",
+				Password: "new-password",
+				Confirm:  "new-password",
+			},
+			mockBehavior:   func(mockRedis redismock.ClientMock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockRedis)
+
+			// Create request body
+			var reqBody []byte
+			var err error
+			if body, ok := tt.requestBody.(models.ResetPasswordRequest); ok {
+				reqBody, err = json.Marshal(body)
+				assert.NoError(t, err)
+			} else {
+				reqBody = []byte(tt.requestBody.(string))
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/reset-password", bytes.NewBuffer(reqBody))
+			assert.NoError(t, err)
+
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			// Ensure all expectations were met
+			if err := mockRedis.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet mock expectations: %v", err)
+			}
+		})
+	}
+
+	var user entities.User
+	common.DB.First(&user, 1)
+	assert.True(t, common.CheckPasswordHash("new-password", user.Password))
+}
+
+func TestRefresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.POST("/refresh", authHandler.Refresh)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	// Mock Redis setup
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+
+	signingKey, err := common.GenerateSigningKey()
+	assert.NoError(t, err)
+	common.SetKeyring(common.NewKeyring(signingKey))
+
+	createSession := func(id string, revokedAt *time.Time) {
+		common.DB.Create(&entities.Session{
+			ID:        id,
+			UserID:    1,
+			ExpiresAt: time.Now().Add(time.Hour),
+			RevokedAt: revokedAt,
+		})
+	}
+
+	createRefreshToken := func(sessionID, token string, expiresAt time.Time, usedAt *time.Time) {
+		common.DB.Create(&entities.RefreshToken{
+			SessionID: sessionID,
+			TokenHash: common.HashToken(token),
+			ExpiresAt: expiresAt,
+			UsedAt:    usedAt,
+		})
+	}
+
+	expectStoreSession := func(mockRedis redismock.ClientMock) {
+		mockRedis.Regexp().ExpectSet(`.+`, "token", 15*time.Minute).SetVal("OK")
+		mockRedis.Regexp().ExpectSAdd("user_sessions:1", `.+`).SetVal(1)
+		mockRedis.ExpectExpire("user_sessions:1", 15*time.Minute).SetVal(true)
+	}
+
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		mockBehavior   func(mockRedis redismock.ClientMock)
+		expectedStatus int
+		sessionID      string
+		revoked        bool
+	}{
+		{
+			name:           "Failed to bind JSON",
+			requestBody:    "invalid json",
+			mockBehavior:   func(mockRedis redismock.ClientMock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Validation error",
+			requestBody:    models.RefreshRequest{},
+			mockBehavior:   func(mockRedis redismock.ClientMock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unknown refresh token",
+			requestBody:    models.RefreshRequest{RefreshToken: "unknown"},
+			mockBehavior:   func(mockRedis redismock.ClientMock) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "Expired refresh token",
+			requestBody: models.RefreshRequest{RefreshToken: "expired"},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				createSession("expired-session", nil)
+				createRefreshToken("expired-session", "expired", time.Now().Add(-time.Minute), nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			sessionID:      "expired-session",
+		},
+		{
+			name:        "Revoked session",
+			requestBody: models.RefreshRequest{RefreshToken: "revoked"},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				createSession("revoked-session", &usedAt)
+				createRefreshToken("revoked-session", "revoked", time.Now().Add(time.Hour), nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			sessionID:      "revoked-session",
+			revoked:        true,
+		},
+		{
+			name:        "Reused refresh token revokes the session",
+			requestBody: models.RefreshRequest{RefreshToken: "reused"},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				createSession("reused-session", nil)
+				createRefreshToken("reused-session", "reused", time.Now().Add(time.Hour), &usedAt)
+				createRefreshToken("reused-session", "latest", time.Now().Add(time.Hour), nil)
+				mockRedis.ExpectSet("revoked_session:reused-session", "revoked", 15*time.Minute).SetVal("OK")
+			},
+			expectedStatus: http.StatusUnauthorized,
+			sessionID:      "reused-session",
+			revoked:        true,
+		},
+		{
+			name:        "Successfully refreshed",
+			requestBody: models.RefreshRequest{RefreshToken: "valid"},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				createSession("valid-session", nil)
+				createRefreshToken("valid-session", "valid", time.Now().Add(time.Hour), nil)
+				expectStoreSession(mockRedis)
+			},
+			expectedStatus: http.StatusOK,
+			sessionID:      "valid-session",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockRedis)
+
+			// Create request body
+			var reqBody []byte
+			var err error
+			if body, ok := tt.requestBody.(models.RefreshRequest); ok {
+				reqBody, err = json.Marshal(body)
+				assert.NoError(t, err)
+			} else {
+				reqBody = []byte(tt.requestBody.(string))
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/refresh", bytes.NewBuffer(reqBody))
+			assert.NoError(t, err)
+
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.sessionID != "" {
+				var session entities.Session
+				assert.NoError(t, common.DB.First(&session, "id = ?", tt.sessionID).Error)
+				assert.Equal(t, tt.revoked, session.RevokedAt != nil)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.LoginResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.NotEmpty(t, response.Token)
+				assert.NotEmpty(t, response.RefreshToken)
+				assert.NotEqual(t, tt.requestBody.(models.RefreshRequest).RefreshToken, response.RefreshToken)
+
+				// The rotated token can't be used again
+				var refreshToken entities.RefreshToken
+				common.DB.Where("token_hash = ?", common.HashToken("valid")).First(&refreshToken)
+				assert.NotNil(t, refreshToken.UsedAt)
+			}
+
+			if err := mockRedis.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	ed25519Key, err := common.GenerateSigningKey()
+	assert.NoError(t, err)
+
+	rsaPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	rsaKey, err := common.NewSigningKey(rsaPrivateKey)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		signingKey  *common.SigningKey
+		expectedAlg string
+	}{
+		{
+			name:        "Ed25519 key",
+			signingKey:  ed25519Key,
+			expectedAlg: "EdDSA",
+		},
+		{
+			name:        "RSA key",
+			signingKey:  rsaKey,
+			expectedAlg: "RS256",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetKeyring(common.NewKeyring(tt.signingKey))
+
+			req, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var keySet common.JWKSet
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &keySet))
+			assert.Len(t, keySet.Keys, 1)
+			assert.Equal(t, tt.expectedAlg, keySet.Keys[0].Algorithm)
+			assert.Equal(t, tt.signingKey.ID, keySet.Keys[0].KeyID)
+
+			// A token signed by the service verifies with the published key only
+			token, err := common.CreateToken(1, "session", time.Minute)
+			assert.NoError(t, err)
+
+			publicKey, err := keySet.Keys[0].PublicKey()
+			assert.NoError(t, err)
+
+			claims, err := common.ParseToken(token, func(token *jwt.Token) (interface{}, error) {
+				return publicKey, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(1), claims.UserID)
+			assert.Equal(t, "session", claims.SessionID)
+		})
+	}
+}
+
+func TestJWKSKeyRotation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	loadKeyring := func() {
+		keys, err := common.LoadSigningKeys(testDB)
+		assert.NoError(t, err)
+		common.SetKeyring(common.NewKeyring(keys...))
+	}
+
+	getKeySet := func() common.JWKSet {
+		req, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+		assert.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var keySet common.JWKSet
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &keySet))
+		return keySet
+	}
+
+	tokenKeyID := func(tokenString string) string {
+		token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+		assert.NoError(t, err)
+		return token.Header["kid"].(string)
+	}
+
+	firstKey, err := common.GenerateSigningKey()
+	assert.NoError(t, err)
+	firstKey.ActivatesAt = time.Now().Add(-time.Hour)
+	assert.NoError(t, common.SaveSigningKey(testDB, firstKey))
+	loadKeyring()
+
+	oldToken, err := common.CreateToken(1, "session", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, firstKey.ID, tokenKeyID(oldToken))
+
+	// A key with a publish delay is in the key set but doesn't sign yet
+	pendingKey, err := common.RotateSigningKeys(testDB, time.Hour, time.Minute)
+	assert.NoError(t, err)
+	loadKeyring()
+
+	assert.Len(t, getKeySet().Keys, 2)
+	token, err := common.CreateToken(1, "session", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, firstKey.ID, tokenKeyID(token))
+
+	// An active new key signs, tokens of the replaced key still verify
+	newKey, err := common.RotateSigningKeys(testDB, 0, time.Minute)
+	assert.NoError(t, err)
+	loadKeyring()
+
+	assert.Len(t, getKeySet().Keys, 3)
+	token, err = common.CreateToken(1, "session", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, newKey.ID, tokenKeyID(token))
+
+	_, err = common.ValidateToken(oldToken)
+	assert.NoError(t, err)
+
+	// Once the replaced keys expire they are retired
+	testDB.Model(&entities.SigningKey{}).
+		Where("kid IN ?", []string{firstKey.ID, pendingKey.ID}).
+		Update("expires_at", time.Now().Add(-time.Second))
+
+	retired, err := common.RetireSigningKeys(testDB)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), retired)
+	loadKeyring()
+
+	keySet := getKeySet()
+	if assert.Len(t, keySet.Keys, 1) {
+		assert.Equal(t, newKey.ID, keySet.Keys[0].KeyID)
+	}
+
+	_, err = common.ValidateToken(oldToken)
+	assert.Error(t, err)
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.GET("/email/confirm", authHandler.ConfirmEmailChange)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.User{ID: 1, Email: "old@example.com", Name: "Test User"})
+	common.DB.Create(&entities.User{ID: 2, Email: "taken@example.com", Name: "Other User"})
+
+	common.DB.Create(&entities.EmailChangeToken{UserID: 1, NewEmail: "new@example.com", TokenHash: common.HashToken("valid"), ExpiresAt: time.Now().Add(time.Hour)})
+	common.DB.Create(&entities.EmailChangeToken{UserID: 1, NewEmail: "late@example.com", TokenHash: common.HashToken("expired"), ExpiresAt: time.Now().Add(-time.Hour)})
+	common.DB.Create(&entities.EmailChangeToken{UserID: 1, NewEmail: "taken@example.com", TokenHash: common.HashToken("taken"), ExpiresAt: time.Now().Add(time.Hour)})
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+		expectedEmail  string
+	}{
+		{name: "Missing token", expectedStatus: http.StatusBadRequest, expectedEmail: "old@example.com"},
+		{name: "Unknown token", token: "unknown", expectedStatus: http.StatusBadRequest, expectedEmail: "old@example.com"},
+		{name: "Expired token", token: "expired", expectedStatus: http.StatusBadRequest, expectedEmail: "old@example.com"},
+		{name: "Email registered meanwhile", token: "taken", expectedStatus: http.StatusConflict, expectedEmail: "old@example.com"},
+		{name: "Successful change", token: "valid", expectedStatus: http.StatusOK, expectedEmail: "new@example.com"},
+		{name: "Token is already used", token: "valid", expectedStatus: http.StatusBadRequest, expectedEmail: "new@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/email/confirm?token="+tt.token, nil)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var user entities.User
+			common.DB.First(&user, 1)
+			assert.Equal(t, tt.expectedEmail, user.Email)
+		})
+	}
+}
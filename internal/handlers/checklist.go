@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var errChecklistMismatch = errors.New("item_ids must list every item of the todo once")
+
+type ChecklistHandler interface {
+	ListItems(context *gin.Context)
+	AddItem(context *gin.Context)
+	UpdateItem(context *gin.Context)
+	ReorderItems(context *gin.Context)
+	DeleteItem(context *gin.Context)
+}
+
+type checklistHandler struct {
+	validate *validator.Validate
+}
+
+func NewChecklistHandler() ChecklistHandler {
+	return &checklistHandler{
+		validate: validator.New(),
+	}
+}
+
+func (h *checklistHandler) ListItems(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	todo, ok := h.findTodo(context, userID.(uint64), todoRead)
+	if !ok {
+		return
+	}
+
+	var items []entities.ChecklistItem
+	result := common.DB.Where("todo_id = ?", todo.ID).Order("position, id").Find(&items)
+	if result.Error != nil {
+		zap.L().Error("Failed to list checklist items",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response := make([]models.ChecklistItemResponse, 0, len(items))
+	for _, item := range items {
+		response = append(response, newChecklistItemResponse(item))
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+// AddItem adds an item at the end of the checklist.
+func (h *checklistHandler) AddItem(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	todo, ok := h.findTodo(context, userID.(uint64), todoWrite)
+	if !ok {
+		return
+	}
+
+	var request models.ChecklistItemRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	item := entities.ChecklistItem{
+		TodoID:      todo.ID,
+		Description: request.Description,
+		Done:        request.Done,
+	}
+
+	err := common.DB.Model(&entities.ChecklistItem{}).
+		Select("COALESCE(MAX(position) + 1, 0)").
+		Where("todo_id = ?", todo.ID).
+		Scan(&item.Position).Error
+	if err == nil {
+		err = common.DB.Create(&item).Error
+	}
+	if err != nil {
+		zap.L().Error("Failed to add checklist item",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Checklist item added successfully",
+		zap.Uint64("todo ID", todo.ID),
+		zap.Uint64("item ID", item.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusCreated, newChecklistItemResponse(item))
+}
+
+// UpdateItem changes the description of an item or completes it.
+func (h *checklistHandler) UpdateItem(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	todo, ok := h.findTodo(context, userID.(uint64), todoWrite)
+	if !ok {
+		return
+	}
+
+	item, ok := h.findItem(context, todo.ID)
+	if !ok {
+		return
+	}
+
+	var request models.ChecklistItemRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	item.Description = request.Description
+	item.Done = request.Done
+
+	result := common.DB.Save(&item)
+	if result.Error != nil {
+		zap.L().Error("Failed to update checklist item",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Checklist item updated successfully",
+		zap.Uint64("todo ID", todo.ID),
+		zap.Uint64("item ID", item.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, newChecklistItemResponse(item))
+}
+
+func (h *checklistHandler) ReorderItems(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	todo, ok := h.findTodo(context, userID.(uint64), todoWrite)
+	if !ok {
+		return
+	}
+
+	var request models.ReorderChecklistRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		var itemIDs []uint64
+		if err := tx.Model(&entities.ChecklistItem{}).Where("todo_id = ?", todo.ID).Pluck("id", &itemIDs).Error; err != nil {
+			return err
+		}
+
+		if !sameItems(itemIDs, request.ItemIDs) {
+			return errChecklistMismatch
+		}
+
+		for position, itemID := range request.ItemIDs {
+			err := tx.Model(&entities.ChecklistItem{}).Where("id = ?", itemID).Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if errors.Is(err, errChecklistMismatch) {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to reorder checklist",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Checklist reordered successfully",
+		zap.Uint64("todo ID", todo.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Checklist reordered successfully"})
+}
+
+func (h *checklistHandler) DeleteItem(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	todo, ok := h.findTodo(context, userID.(uint64), todoWrite)
+	if !ok {
+		return
+	}
+
+	item, ok := h.findItem(context, todo.ID)
+	if !ok {
+		return
+	}
+
+	result := common.DB.Delete(&item)
+	if result.Error != nil {
+		zap.L().Error("Failed to delete checklist item",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Checklist item deleted successfully",
+		zap.Uint64("todo ID", todo.ID),
+		zap.Uint64("item ID", item.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Checklist item deleted successfully"})
+}
+
+func (h *checklistHandler) bind(context *gin.Context, request interface{}) bool {
+	if err := context.ShouldBindJSON(request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// findTodo loads the todo of the request and checks that the user may do the
+// action with its checklist.
+func (h *checklistHandler) findTodo(context *gin.Context, userID uint64, action todoAction) (entities.Todo, bool) {
+	var todo entities.Todo
+
+	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return todo, false
+	}
+
+	result := common.DB.First(&todo, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		zap.L().Error("Todo not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return todo, false
+	}
+	if result.Error != nil {
+		zap.L().Error("Failed to find todo",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return todo, false
+	}
+
+	return todo, todoAllowed(context, todo, userID, action)
+}
+
+func (h *checklistHandler) findItem(context *gin.Context, todoID uint64) (entities.ChecklistItem, bool) {
+	var item entities.ChecklistItem
+
+	id, err := strconv.ParseUint(context.Param("itemId"), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid item ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return item, false
+	}
+
+	result := common.DB.Where("id = ? AND todo_id = ?", id, todoID).First(&item)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		zap.L().Error("Checklist item not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Checklist item not found"})
+		return item, false
+	}
+	if result.Error != nil {
+		zap.L().Error("Failed to find checklist item",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return item, false
+	}
+
+	return item, true
+}
+
+// sameItems reports whether ordered lists every id of itemIDs exactly once.
+func sameItems(itemIDs, ordered []uint64) bool {
+	if len(itemIDs) != len(ordered) {
+		return false
+	}
+
+	remaining := make(map[uint64]bool, len(itemIDs))
+	for _, itemID := range itemIDs {
+		remaining[itemID] = true
+	}
+
+	for _, itemID := range ordered {
+		if !remaining[itemID] {
+			return false
+		}
+		delete(remaining, itemID)
+	}
+
+	return true
+}
+
+// loadChecklistProgress fills in the progress of the todos with a checklist.
+func loadChecklistProgress(responses ...*models.TodoResponse) error {
+	if len(responses) == 0 {
+		return nil
+	}
+
+	todoIDs := make([]uint64, 0, len(responses))
+	for _, response := range responses {
+		todoIDs = append(todoIDs, response.ID)
+	}
+
+	var rows []struct {
+		TodoID uint64
+		Done   int
+		Total  int
+	}
+	err := common.DB.Model(&entities.ChecklistItem{}).
+		Select("todo_id, SUM(CASE WHEN done THEN 1 ELSE 0 END) AS done, COUNT(*) AS total").
+		Where("todo_id IN ?", todoIDs).
+		Group("todo_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	progress := make(map[uint64]models.ChecklistProgress, len(rows))
+	for _, row := range rows {
+		progress[row.TodoID] = models.ChecklistProgress{Done: row.Done, Total: row.Total}
+	}
+
+	for _, response := range responses {
+		if todoProgress, ok := progress[response.ID]; ok {
+			response.Progress = &todoProgress
+		}
+	}
+
+	return nil
+}
+
+func newChecklistItemResponse(item entities.ChecklistItem) models.ChecklistItemResponse {
+	return models.ChecklistItemResponse{
+		ID:          item.ID,
+		Description: item.Description,
+		Done:        item.Done,
+		Position:    item.Position,
+		CreatedAt:   item.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   item.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,119 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/internal/handlers"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+func setupChecklistRouter() *gin.Engine {
+	todoHandler := handlers.NewTodoHandler()
+	checklistHandler := handlers.NewChecklistHandler()
+
+	router := newUserRouter()
+	router.GET("/todo", todoHandler.ListTodos)
+	router.GET("/todo/:id", todoHandler.ReadTodo)
+	router.PUT("/todo/:id", todoHandler.UpdateTodo)
+	router.GET("/todo/:id/items", checklistHandler.ListItems)
+	router.POST("/todo/:id/items", checklistHandler.AddItem)
+	router.PUT("/todo/:id/items/order", checklistHandler.ReorderItems)
+	router.PUT("/todo/:id/items/:itemId", checklistHandler.UpdateItem)
+	router.DELETE("/todo/:id/items/:itemId", checklistHandler.DeleteItem)
+
+	return router
+}
+
+func TestChecklist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	// Todo 1 is shared with user 2 to read
+	common.DB.Create(&entities.Todo{ID: 1, Description: "Bake a cake", Status: "pending", UserID: 1})
+	common.DB.Create(&entities.Todo{ID: 2, Description: "Other Todo", Status: "pending", UserID: 1})
+	common.DB.Create(&entities.ChecklistItem{ID: 9, TodoID: 2, Description: "Other item"})
+	common.DB.Create(&entities.TodoShare{TodoID: 1, UserID: 2, Permission: string(models.ShareRead)})
+
+	router := setupChecklistRouter()
+
+	tests := []struct {
+		name           string
+		userID         uint64
+		method         string
+		path           string
+		body           interface{}
+		expectedStatus int
+	}{
+		{name: "Missing Description", userID: 1, method: http.MethodPost, path: "/todo/1/items", body: models.ChecklistItemRequest{}, expectedStatus: http.StatusBadRequest},
+		{name: "Add First", userID: 1, method: http.MethodPost, path: "/todo/1/items", body: models.ChecklistItemRequest{Description: "Buy flour"}, expectedStatus: http.StatusCreated},
+		{name: "Add Second", userID: 1, method: http.MethodPost, path: "/todo/1/items", body: models.ChecklistItemRequest{Description: "Preheat oven"}, expectedStatus: http.StatusCreated},
+		{name: "Add Third", userID: 1, method: http.MethodPost, path: "/todo/1/items", body: models.ChecklistItemRequest{Description: "Mix", Done: true}, expectedStatus: http.StatusCreated},
+		{name: "Reader Adds", userID: 2, method: http.MethodPost, path: "/todo/1/items", body: models.ChecklistItemRequest{Description: "Eat"}, expectedStatus: http.StatusForbidden},
+		{name: "Reader Lists", userID: 2, method: http.MethodGet, path: "/todo/1/items", expectedStatus: http.StatusOK},
+		{name: "Complete Item", userID: 1, method: http.MethodPut, path: "/todo/1/items/10", body: models.ChecklistItemRequest{Description: "Buy flour", Done: true}, expectedStatus: http.StatusOK},
+		{name: "Item Of Other Todo", userID: 1, method: http.MethodPut, path: "/todo/1/items/9", body: models.ChecklistItemRequest{Description: "Mine now"}, expectedStatus: http.StatusNotFound},
+		{name: "Reorder Missing Item", userID: 1, method: http.MethodPut, path: "/todo/1/items/order", body: models.ReorderChecklistRequest{ItemIDs: []uint64{12, 10}}, expectedStatus: http.StatusBadRequest},
+		{name: "Reorder Item Of Other Todo", userID: 1, method: http.MethodPut, path: "/todo/1/items/order", body: models.ReorderChecklistRequest{ItemIDs: []uint64{12, 10, 9}}, expectedStatus: http.StatusBadRequest},
+		{name: "Reorder Twice The Same", userID: 1, method: http.MethodPut, path: "/todo/1/items/order", body: models.ReorderChecklistRequest{ItemIDs: []uint64{12, 10, 10}}, expectedStatus: http.StatusBadRequest},
+		{name: "Reorder", userID: 1, method: http.MethodPut, path: "/todo/1/items/order", body: models.ReorderChecklistRequest{ItemIDs: []uint64{12, 10, 11}}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(router, tt.userID, tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	w := serveAs(router, 1, http.MethodGet, "/todo/1/items", nil)
+	var items []models.ChecklistItemResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+	if assert.Len(t, items, 3) {
+		assert.Equal(t, []string{"Mix", "Buy flour", "Preheat oven"},
+			[]string{items[0].Description, items[1].Description, items[2].Description})
+	}
+
+	readProgress := func(path string) *models.ChecklistProgress {
+		w := serveAs(router, 1, http.MethodGet, path, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var todo models.TodoResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todo))
+		return todo.Progress
+	}
+
+	assert.Equal(t, &models.ChecklistProgress{Done: 2, Total: 3}, readProgress("/todo/1"))
+
+	// Deleting an item counts too
+	w = serveAs(router, 1, http.MethodDelete, "/todo/1/items/12", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, &models.ChecklistProgress{Done: 1, Total: 2}, readProgress("/todo/1"))
+
+	// Completing the todo leaves the checklist alone unless asked to
+	w = serveAs(router, 1, http.MethodPut, "/todo/1", models.TodoUpdateRequest{Description: "Bake a cake", Status: models.Completed})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, &models.ChecklistProgress{Done: 1, Total: 2}, readProgress("/todo/1"))
+
+	w = serveAs(router, 1, http.MethodPut, "/todo/1", models.TodoUpdateRequest{Description: "Bake a cake", Status: models.Completed, CompleteItems: true})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, &models.ChecklistProgress{Done: 2, Total: 2}, readProgress("/todo/1"))
+
+	// The other todo keeps its own progress
+	w = serveAs(router, 1, http.MethodGet, "/todo", nil)
+	var response models.TodoListResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	for _, todo := range response.Items {
+		if todo.ID == 2 {
+			assert.Equal(t, &models.ChecklistProgress{Done: 0, Total: 1}, todo.Progress)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const emailChangeTTL = 24 * time.Hour
+
+var (
+	errEmailChangeUsed = errors.New("email change token is already used")
+	errEmailRegistered = errors.New("email is already registered")
+)
+
+// emailRegistered tells whether an account other than the given user has the
+// email. Registration and email changes check it alike.
+func emailRegistered(db *gorm.DB, email string, exceptUserID uint64) (bool, error) {
+	var count int64
+	err := db.Model(&entities.User{}).
+		Where("email = ? AND id <> ?", email, exceptUserID).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+func (h *authHandler) ConfirmEmailChange(context *gin.Context) {
+	token := context.Query("token")
+	if token == "" {
+		zap.L().Error("Email change token is missing",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Confirmation token is missing"})
+		return
+	}
+
+	var change entities.EmailChangeToken
+	result := common.DB.Where("token_hash = ?", common.HashToken(token)).First(&change)
+	if result.Error != nil || change.UsedAt != nil || change.ExpiresAt.Before(time.Now()) {
+		zap.L().Error("Invalid email change token",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Confirmation token is invalid or expired"})
+		return
+	}
+
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		// Claim the token first, so two concurrent requests can't both use it
+		result := tx.Model(&change).Where("used_at IS NULL").Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errEmailChangeUsed
+		}
+
+		// Someone may have registered the address since the change was requested
+		registered, err := emailRegistered(tx, change.NewEmail, change.UserID)
+		if err != nil {
+			return err
+		}
+		if registered {
+			return errEmailRegistered
+		}
+
+		return tx.Model(&entities.User{}).
+			Where("id = ?", change.UserID).
+			Update("email", change.NewEmail).Error
+	})
+	if errors.Is(err, errEmailChangeUsed) {
+		zap.L().Error("Email change token is already used",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Confirmation token is invalid or expired"})
+		return
+	}
+	if errors.Is(err, errEmailRegistered) {
+		zap.L().Error("This email is already registered",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusConflict, gin.H{"error": "This email is already registered"})
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to change email",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Email changed successfully",
+		zap.Uint64("user ID", change.UserID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Your email address has been changed"})
+}
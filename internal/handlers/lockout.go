@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+)
+
+// loginLocked answers with 429 when logins of the account or from the client
+// IP are locked. Redis errors don't lock anyone out, they are only logged.
+func (h *authHandler) loginLocked(context *gin.Context, email string) bool {
+	lockedUntil, err := common.LoginLockedUntil(context, email, context.ClientIP())
+	if err != nil {
+		zap.L().Error("Failed to check login lockout",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	if lockedUntil.IsZero() {
+		return false
+	}
+
+	zap.L().Error("Login is locked",
+		zap.String("url path", context.Request.URL.Path),
+		zap.Time("locked until", lockedUntil),
+	)
+	tooManyLoginAttempts(context, lockedUntil)
+	return true
+}
+
+// loginFailed counts a failed login, wrong passwords and wrong second factors
+// alike, and answers it with the message after the progressive delay unless
+// that locked the account. The user is nil for unknown emails.
+func (h *authHandler) loginFailed(context *gin.Context, email string, user *entities.User, message string) {
+	failure, err := common.RecordLoginFailure(context, h.loginLimits, email, context.ClientIP())
+	if err != nil {
+		zap.L().Error("Failed to record failed login",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+	}
+
+	if failure.Locked {
+		zap.L().Warn("Account locked after failed logins",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Int64("failures", failure.Failures),
+		)
+
+		if user != nil {
+			h.notifyAccountLocked(context, user, failure.LockedUntil)
+		}
+
+		tooManyLoginAttempts(context, failure.LockedUntil)
+		return
+	}
+
+	select {
+	case <-time.After(common.LoginDelay(h.loginLimits, failure.Failures)):
+	case <-context.Request.Context().Done():
+	}
+
+	context.JSON(http.StatusUnauthorized, gin.H{"error": message})
+}
+
+// loginSucceeded forgets the failed logins of the account once the user is
+// fully logged in, after the second factor if there is one.
+func (h *authHandler) loginSucceeded(context *gin.Context, email string) {
+	if err := common.ClearLoginFailures(context, email); err != nil {
+		zap.L().Error("Failed to clear failed logins",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+	}
+}
+
+// notifyAccountLocked emails the user a link to unlock the account.
+func (h *authHandler) notifyAccountLocked(context *gin.Context, user *entities.User, lockedUntil time.Time) {
+	token, err := common.CreateUnlockToken(context, user.Email, h.loginLimits.Lockout)
+	if err == nil {
+		err = common.EnqueueEvent(common.DB,
+			models.AccountLocked,
+			strconv.FormatUint(user.ID, 10),
+			models.AccountLockedPayload{
+				UserID:      user.ID,
+				Email:       user.Email,
+				Name:        user.Name,
+				Locale:      user.Locale,
+				UnlockToken: token,
+				LockedUntil: lockedUntil,
+			},
+		)
+	}
+	if err != nil {
+		zap.L().Error("Failed to notify about locked account",
+			zap.Uint64("user ID", user.ID),
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+	}
+}
+
+func tooManyLoginAttempts(context *gin.Context, lockedUntil time.Time) {
+	retryAfter := math.Ceil(time.Until(lockedUntil).Seconds())
+	context.Header("Retry-After", strconv.Itoa(max(int(retryAfter), 1)))
+	context.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+}
+
+func (h *authHandler) Unlock(context *gin.Context) {
+	token := context.Query("token")
+	if token == "" {
+		zap.L().Error("Unlock token is missing",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Unlock token is missing"})
+		return
+	}
+
+	_, err := common.UnlockAccount(context, token)
+	if errors.Is(err, common.ErrInvalidUnlockToken) {
+		zap.L().Error("Invalid unlock token",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Unlock token is invalid or expired"})
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to unlock account",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Account unlocked",
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Your account has been unlocked"})
+}
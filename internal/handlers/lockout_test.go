@@ -0,0 +1,338 @@
+package handlers_test
+
+import (
+	"bytes"
+	stdcontext "context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/internal/handlers"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("LOGIN_DELAY", "1ms")
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.POST("/login", authHandler.Login)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.User{
+		ID:       1,
+		Email:    "user@example.com",
+		Name:     "Test User",
+		Password: common.HashPassword("password"),
+		Verified: true,
+	})
+
+	// Mock Redis setup
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+
+	lockKeys := []string{"login_lock:account:user@example.com", "login_lock:ip:10.0.0.1"}
+	lockedUntil := strconv.FormatInt(time.Now().Add(10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name           string
+		password       string
+		mockBehavior   func()
+		expectedStatus int
+	}{
+		{
+			name:     "Locked Account",
+			password: "password",
+			mockBehavior: func() {
+				mockRedis.ExpectMGet(lockKeys...).SetVal([]interface{}{lockedUntil, nil})
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:     "Locked IP",
+			password: "password",
+			mockBehavior: func() {
+				mockRedis.ExpectMGet(lockKeys...).SetVal([]interface{}{nil, lockedUntil})
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:     "Failed Login Is Counted",
+			password: "wrong-password",
+			mockBehavior: func() {
+				mockRedis.ExpectMGet(lockKeys...).SetVal([]interface{}{nil, nil})
+				mockRedis.ExpectIncr("login_failures:ip:10.0.0.1").SetVal(1)
+				mockRedis.ExpectExpire("login_failures:ip:10.0.0.1", 15*time.Minute).SetVal(true)
+				mockRedis.ExpectIncr("login_failures:account:user@example.com").SetVal(2)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:     "Too Many Failed Logins Lock The Account",
+			password: "wrong-password",
+			mockBehavior: func() {
+				mockRedis.ExpectMGet(lockKeys...).SetVal([]interface{}{nil, nil})
+				mockRedis.ExpectIncr("login_failures:ip:10.0.0.1").SetVal(5)
+				mockRedis.ExpectIncr("login_failures:account:user@example.com").SetVal(5)
+				mockRedis.Regexp().ExpectSet("login_lock:account:user@example.com", `\d+`, 15*time.Minute).SetVal("OK")
+				mockRedis.ExpectDel("login_failures:account:user@example.com").SetVal(1)
+				mockRedis.Regexp().ExpectSet(`login_unlock:.+`, "user@example.com", 15*time.Minute).SetVal("OK")
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:     "Too Many Failed Logins From The IP",
+			password: "wrong-password",
+			mockBehavior: func() {
+				mockRedis.ExpectMGet(lockKeys...).SetVal([]interface{}{nil, nil})
+				mockRedis.ExpectIncr("login_failures:ip:10.0.0.1").SetVal(50)
+				mockRedis.Regexp().ExpectSet("login_lock:ip:10.0.0.1", `\d+`, 15*time.Minute).SetVal("OK")
+				mockRedis.ExpectDel("login_failures:ip:10.0.0.1").SetVal(1)
+				mockRedis.ExpectIncr("login_failures:account:user@example.com").SetVal(1)
+				mockRedis.ExpectExpire("login_failures:account:user@example.com", 15*time.Minute).SetVal(true)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRedis.ClearExpect()
+			tt.mockBehavior()
+
+			reqBody, err := json.Marshal(models.LoginRequest{Email: "user@example.com", Password: tt.password})
+			assert.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(reqBody))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = "10.0.0.1:1234"
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusTooManyRequests {
+				assert.NotEmpty(t, w.Header().Get("Retry-After"))
+			}
+
+			if err := mockRedis.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+
+	// Only the lockout of a known account is emailed
+	var events []entities.OutboxEvent
+	common.DB.Where("event_type = ?", models.AccountLocked).Find(&events)
+	assert.Len(t, events, 1)
+}
+
+func TestLoginDelay(t *testing.T) {
+	limits := common.LoginLimits{Delay: 250 * time.Millisecond, MaxDelay: 4 * time.Second}
+
+	tests := []struct {
+		name          string
+		limits        common.LoginLimits
+		failures      int64
+		expectedDelay time.Duration
+	}{
+		{name: "No Failures", limits: limits, failures: 0, expectedDelay: 0},
+		{name: "First Failure", limits: limits, failures: 1, expectedDelay: 250 * time.Millisecond},
+		{name: "Second Failure", limits: limits, failures: 2, expectedDelay: 500 * time.Millisecond},
+		{name: "Fifth Failure", limits: limits, failures: 5, expectedDelay: 4 * time.Second},
+		{name: "Capped", limits: limits, failures: 1000, expectedDelay: 4 * time.Second},
+		{name: "Disabled", limits: common.LoginLimits{MaxDelay: 4 * time.Second}, failures: 3, expectedDelay: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedDelay, common.LoginDelay(tt.limits, tt.failures))
+		})
+	}
+}
+
+func TestLoginBackOff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("LOGIN_DELAY", "20ms")
+	t.Setenv("LOGIN_MAX_DELAY", "80ms")
+	t.Setenv("LOGIN_MAX_FAILURES", "100")
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.POST("/login", authHandler.Login)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	// A cheap hash, so the timings only measure the delay
+	password, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	common.DB.Create(&entities.User{
+		ID:       1,
+		Email:    "user@example.com",
+		Name:     "Test User",
+		Password: string(password),
+		Verified: true,
+	})
+
+	// Mock Redis setup
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+
+	lockKeys := []string{"login_lock:account:user@example.com", "login_lock:ip:10.0.0.1"}
+
+	tests := []struct {
+		name        string
+		failures    int64
+		minDuration time.Duration
+		maxDuration time.Duration
+	}{
+		{name: "First Failure", failures: 1, minDuration: 20 * time.Millisecond, maxDuration: time.Second},
+		{name: "Second Failure", failures: 2, minDuration: 40 * time.Millisecond, maxDuration: time.Second},
+		{name: "Capped", failures: 10, minDuration: 80 * time.Millisecond, maxDuration: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRedis.ClearExpect()
+			mockRedis.ExpectMGet(lockKeys...).SetVal([]interface{}{nil, nil})
+			mockRedis.ExpectIncr("login_failures:ip:10.0.0.1").SetVal(2)
+			mockRedis.ExpectIncr("login_failures:account:user@example.com").SetVal(tt.failures)
+			if tt.failures == 1 {
+				mockRedis.ExpectExpire("login_failures:account:user@example.com", 15*time.Minute).SetVal(true)
+			}
+
+			reqBody, err := json.Marshal(models.LoginRequest{Email: "user@example.com", Password: "wrong-password"})
+			assert.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(reqBody))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = "10.0.0.1:1234"
+
+			w := httptest.NewRecorder()
+			start := time.Now()
+			router.ServeHTTP(w, req)
+			elapsed := time.Since(start)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.GreaterOrEqual(t, elapsed, tt.minDuration)
+			assert.Less(t, elapsed, tt.maxDuration)
+
+			if err := mockRedis.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+
+	t.Run("Cancelled Request", func(t *testing.T) {
+		t.Setenv("LOGIN_DELAY", "1h")
+		t.Setenv("LOGIN_MAX_DELAY", "1h")
+
+		router := gin.Default()
+		router.POST("/login", handlers.NewAuthHandler().Login)
+
+		mockRedis.ClearExpect()
+		mockRedis.ExpectMGet(lockKeys...).SetVal([]interface{}{nil, nil})
+		mockRedis.ExpectIncr("login_failures:ip:10.0.0.1").SetVal(2)
+		mockRedis.ExpectIncr("login_failures:account:user@example.com").SetVal(2)
+
+		reqBody, err := json.Marshal(models.LoginRequest{Email: "user@example.com", Password: "wrong-password"})
+		assert.NoError(t, err)
+
+		ctx, cancel := stdcontext.WithTimeout(stdcontext.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/login", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "10.0.0.1:1234"
+
+		w := httptest.NewRecorder()
+		start := time.Now()
+		router.ServeHTTP(w, req)
+
+		// The client is gone, so the answer isn't held back for the delay
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestUnlock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.GET("/unlock", authHandler.Unlock)
+
+	// Mock Redis setup
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+
+	tests := []struct {
+		name           string
+		token          string
+		mockBehavior   func()
+		expectedStatus int
+	}{
+		{
+			name:           "Missing Token",
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Invalid Token",
+			token: "invalid",
+			mockBehavior: func() {
+				mockRedis.ExpectGetDel("login_unlock:" + common.HashToken("invalid")).RedisNil()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Successful Unlock",
+			token: "valid",
+			mockBehavior: func() {
+				mockRedis.ExpectGetDel("login_unlock:" + common.HashToken("valid")).SetVal("user@example.com")
+				mockRedis.ExpectDel("login_failures:account:user@example.com", "login_lock:account:user@example.com").SetVal(1)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior()
+
+			req, err := http.NewRequest(http.MethodGet, "/unlock?token="+tt.token, nil)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if err := mockRedis.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const mfaChallengeTTL = 5 * time.Minute
+
+func (h *authHandler) VerifyMFA(context *gin.Context) {
+	var request models.VerifyMFARequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := common.MFAChallengeUser(context, request.MFAToken)
+	if errors.Is(err, common.ErrInvalidMFAChallenge) {
+		zap.L().Error("Invalid mfa challenge",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusUnauthorized, gin.H{"error": "MFA token is invalid or expired"})
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to get mfa challenge",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user entities.User
+	result := common.DB.First(&user, userID)
+	if result.Error != nil {
+		zap.L().Error("Failed to find user",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusUnauthorized, gin.H{"error": "MFA token is invalid or expired"})
+		return
+	}
+
+	// Wrong codes count against the account and IP, so new challenges don't
+	// allow more guesses
+	if h.loginLocked(context, user.Email) {
+		return
+	}
+
+	// Authenticator codes are digits, anything else is tried as a recovery code
+	var valid bool
+	if len(request.Code) == 6 && h.validate.Var(request.Code, "numeric") == nil {
+		valid, err = useTOTPCode(common.DB, &user, request.Code)
+	} else {
+		valid, err = useRecoveryCode(common.DB, user.ID, request.Code)
+	}
+	if err != nil {
+		zap.L().Error("Failed to verify code",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !valid {
+		if err := common.FailMFAChallenge(context, request.MFAToken); err != nil {
+			zap.L().Error("Failed to count mfa attempt", zap.Error(err))
+		}
+
+		zap.L().Error("Invalid mfa code",
+			zap.Uint64("user ID", user.ID),
+			zap.String("url path", context.Request.URL.Path),
+		)
+		h.loginFailed(context, user.Email, &user, "Invalid code")
+		return
+	}
+
+	if err := common.DeleteMFAChallenge(context, request.MFAToken); err != nil {
+		zap.L().Error("Failed to delete mfa challenge",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	loginResponse, err := h.startSession(context, user.ID)
+	if err != nil {
+		zap.L().Error("Failed to create session",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.loginSucceeded(context, user.Email)
+
+	zap.L().Info("User logged in successfully with mfa",
+		zap.Uint64("user ID", user.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, loginResponse)
+}
+
+func (h *authHandler) EnrollTOTP(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var user entities.User
+	result := common.DB.First(&user, userID)
+	if result.Error != nil {
+		zap.L().Error("Failed to find user",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if user.TotpEnabled {
+		zap.L().Error("Two-factor authentication is already enabled",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := common.GenerateTOTPSecret()
+	if err != nil {
+		zap.L().Error("Failed to generate totp secret",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The secret is pending until EnableTOTP verifies a first code
+	result = common.DB.Model(&user).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	})
+	if result.Error != nil {
+		zap.L().Error("Failed to save totp secret",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, models.TOTPEnrollResponse{
+		Secret:     secret,
+		OtpauthURL: common.TOTPURI(h.totpIssuer, user.Email, secret),
+	})
+}
+
+func (h *authHandler) EnableTOTP(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var request models.TOTPCodeRequest
+	if !h.bindTOTPCode(context, &request) {
+		return
+	}
+
+	var user entities.User
+	result := common.DB.First(&user, userID)
+	if result.Error != nil {
+		zap.L().Error("Failed to find user",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if user.TotpEnabled {
+		zap.L().Error("Two-factor authentication is already enabled",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	if user.TotpSecret == "" {
+		zap.L().Error("Two-factor authentication enrollment is not started",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication enrollment is not started"})
+		return
+	}
+
+	recoveryCodes, err := common.GenerateRecoveryCodes()
+	if err != nil {
+		zap.L().Error("Failed to generate recovery codes",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = common.DB.Transaction(func(tx *gorm.DB) error {
+		valid, err := useTOTPCode(tx, &user, request.Code)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return errInvalidCode
+		}
+
+		if err := tx.Model(&user).Update("totp_enabled", true).Error; err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(tx, user.ID, recoveryCodes)
+	})
+	if errors.Is(err, errInvalidCode) {
+		zap.L().Error("Invalid totp code",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to enable two-factor authentication",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Two-factor authentication enabled",
+		zap.Uint64("user ID", user.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	// Recovery codes are only shown once
+	context.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (h *authHandler) DisableTOTP(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var request models.TOTPCodeRequest
+	if !h.bindTOTPCode(context, &request) {
+		return
+	}
+
+	var user entities.User
+	result := common.DB.First(&user, userID)
+	if result.Error != nil {
+		zap.L().Error("Failed to find user",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if !user.TotpEnabled {
+		zap.L().Error("Two-factor authentication is not enabled",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	// A fresh authenticator code is required, a stolen access token alone can't disable it
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		valid, err := useTOTPCode(tx, &user, request.Code)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return errInvalidCode
+		}
+
+		err = tx.Model(&user).Updates(map[string]interface{}{
+			"totp_secret":    "",
+			"totp_enabled":   false,
+			"totp_last_step": 0,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", user.ID).Delete(&entities.RecoveryCode{}).Error
+	})
+	if errors.Is(err, errInvalidCode) {
+		zap.L().Error("Invalid totp code",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to disable two-factor authentication",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Two-factor authentication disabled",
+		zap.Uint64("user ID", user.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+func (h *authHandler) bindTOTPCode(context *gin.Context, request *models.TOTPCodeRequest) bool {
+	if err := context.ShouldBindJSON(request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// useTOTPCode checks an authenticator code and marks its time step used.
+func useTOTPCode(db *gorm.DB, user *entities.User, code string) (bool, error) {
+	step, ok := common.ValidateTOTP(user.TotpSecret, code, user.TotpLastStep)
+	if !ok {
+		return false, nil
+	}
+
+	// Conditional, so a concurrent request can't use the same code
+	result := db.Model(&entities.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	user.TotpLastStep = step
+	return result.RowsAffected > 0, nil
+}
+
+func useRecoveryCode(db *gorm.DB, userID uint64, code string) (bool, error) {
+	result := db.Model(&entities.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL",
+			userID, common.HashToken(common.NormalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint64, codes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&entities.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	recoveryCodes := make([]entities.RecoveryCode, len(codes))
+	for i, code := range codes {
+		recoveryCodes[i] = entities.RecoveryCode{
+			UserID:   userID,
+			CodeHash: common.HashToken(common.NormalizeRecoveryCode(code)),
+		}
+	}
+
+	return tx.Create(&recoveryCodes).Error
+}
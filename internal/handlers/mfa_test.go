@@ -0,0 +1,396 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/internal/handlers"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+func currentTOTPCode(t *testing.T, secret string) string {
+	code, err := common.TOTPCode(secret, time.Now())
+	assert.NoError(t, err)
+	return code
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.User{ID: 1, Email: "enroll@example.com"})
+	common.DB.Create(&entities.User{ID: 2, Email: "enabled@example.com", TotpSecret: "SECRET", TotpEnabled: true})
+
+	authHandler := handlers.NewAuthHandler()
+
+	tests := []struct {
+		name           string
+		userID         uint64
+		expectedStatus int
+	}{
+		{
+			name:           "Successfully enrolled",
+			userID:         1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Already enabled",
+			userID:         2,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "User not found",
+			userID:         999,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.Default()
+			router.Use(func(c *gin.Context) {
+				c.Set("userID", tt.userID)
+				c.Next()
+			})
+			router.POST("/mfa/totp/enroll", authHandler.EnrollTOTP)
+
+			req, err := http.NewRequest(http.MethodPost, "/mfa/totp/enroll", nil)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.TOTPEnrollResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.True(t, strings.HasPrefix(response.OtpauthURL, "otpauth://totp/"))
+				assert.Contains(t, response.OtpauthURL, "secret="+response.Secret)
+
+				// The secret is pending until a first code is verified
+				var user entities.User
+				common.DB.First(&user, tt.userID)
+				assert.Equal(t, response.Secret, user.TotpSecret)
+				assert.False(t, user.TotpEnabled)
+			}
+		})
+	}
+}
+
+func TestEnableAndDisableTOTP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	secret, err := common.GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	common.DB.Create(&entities.User{ID: 1, Email: "totp@example.com", TotpSecret: secret})
+	common.DB.Create(&entities.User{ID: 2, Email: "notstarted@example.com"})
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseUint(c.GetHeader("X-User"), 10, 64)
+		c.Set("userID", userID)
+		c.Next()
+	})
+	router.POST("/mfa/totp/enable", authHandler.EnableTOTP)
+	router.POST("/mfa/totp/disable", authHandler.DisableTOTP)
+
+	send := func(path, user string, request interface{}) *httptest.ResponseRecorder {
+		reqBody, err := json.Marshal(request)
+		assert.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("/mfa/totp/enable", "2", models.TOTPCodeRequest{Code: "123456"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "enrollment not started")
+
+	w = send("/mfa/totp/enable", "1", models.TOTPCodeRequest{Code: "abc"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "malformed code")
+
+	w = send("/mfa/totp/disable", "1", models.TOTPCodeRequest{Code: currentTOTPCode(t, secret)})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "disable before enabling")
+
+	code := currentTOTPCode(t, secret)
+	wrongCode := "000000"
+	if code == wrongCode {
+		wrongCode = "111111"
+	}
+
+	w = send("/mfa/totp/enable", "1", models.TOTPCodeRequest{Code: wrongCode})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "wrong code")
+
+	w = send("/mfa/totp/enable", "1", models.TOTPCodeRequest{Code: code})
+	assert.Equal(t, http.StatusOK, w.Code, "enable")
+
+	var response models.RecoveryCodesResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.RecoveryCodes, 10)
+
+	var user entities.User
+	common.DB.First(&user, 1)
+	assert.True(t, user.TotpEnabled)
+
+	var count int64
+	common.DB.Model(&entities.RecoveryCode{}).Where("user_id = ?", 1).Count(&count)
+	assert.Equal(t, int64(10), count)
+
+	// The code that enabled it can't be replayed to disable it
+	w = send("/mfa/totp/disable", "1", models.TOTPCodeRequest{Code: code})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "replayed code")
+
+	// Let the next code be fresh
+	common.DB.Model(&entities.User{}).Where("id = ?", 1).Update("totp_last_step", 0)
+
+	w = send("/mfa/totp/disable", "1", models.TOTPCodeRequest{Code: code})
+	assert.Equal(t, http.StatusOK, w.Code, "disable")
+
+	common.DB.First(&user, 1)
+	assert.False(t, user.TotpEnabled)
+	assert.Empty(t, user.TotpSecret)
+
+	common.DB.Model(&entities.RecoveryCode{}).Where("user_id = ?", 1).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestLoginWithMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("LOGIN_DELAY", "1ms")
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.POST("/login", authHandler.Login)
+	router.POST("/login/mfa", authHandler.VerifyMFA)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	// Mock Redis setup
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+
+	signingKey, err := common.GenerateSigningKey()
+	assert.NoError(t, err)
+	common.SetKeyring(common.NewKeyring(signingKey))
+
+	secret, err := common.GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	common.DB.Create(&entities.User{
+		ID:          1,
+		Email:       "mfa@example.com",
+		Name:        "MFA User",
+		Password:    common.HashPassword("password"),
+		Verified:    true,
+		TotpSecret:  secret,
+		TotpEnabled: true,
+	})
+	common.DB.Create(&entities.RecoveryCode{UserID: 1, CodeHash: common.HashToken("ABCDEFGHIJ")})
+
+	challengeKey := "mfa_challenge:" + common.HashToken("challenge")
+	lockKeys := []string{"login_lock:account:mfa@example.com", "login_lock:ip:10.0.0.1"}
+
+	expectChallenge := func(mockRedis redismock.ClientMock) {
+		mockRedis.ExpectHGet(challengeKey, "user_id").SetVal("1")
+		mockRedis.ExpectMGet(lockKeys...).SetVal([]interface{}{nil, nil})
+	}
+
+	// Wrong codes are counted like wrong passwords
+	expectFailure := func(mockRedis redismock.ClientMock, failures int64) {
+		mockRedis.ExpectIncr("login_failures:ip:10.0.0.1").SetVal(failures)
+		mockRedis.ExpectIncr("login_failures:account:mfa@example.com").SetVal(failures)
+	}
+
+	expectSession := func(mockRedis redismock.ClientMock) {
+		mockRedis.ExpectDel(challengeKey).SetVal(1)
+		mockRedis.Regexp().ExpectSet(`.+`, "token", 15*time.Minute).SetVal("OK")
+		mockRedis.Regexp().ExpectSAdd("user_sessions:1", `.+`).SetVal(1)
+		mockRedis.ExpectExpire("user_sessions:1", 15*time.Minute).SetVal(true)
+		mockRedis.ExpectDel("login_failures:account:mfa@example.com", "login_lock:account:mfa@example.com").SetVal(1)
+	}
+
+	t.Run("Login asks for a second factor", func(t *testing.T) {
+		// Failed logins are only cleared once the second factor is verified
+		mockRedis.ExpectMGet(lockKeys...).SetVal([]interface{}{nil, nil})
+		mockRedis.Regexp().ExpectHSet(`mfa_challenge:.+`, "user_id", "1").SetVal(1)
+		mockRedis.Regexp().ExpectExpire(`mfa_challenge:.+`, 5*time.Minute).SetVal(true)
+
+		reqBody, _ := json.Marshal(models.LoginRequest{Email: "mfa@example.com", Password: "password"})
+		req, err := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "10.0.0.1:1234"
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response models.MFAChallengeResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.MFARequired)
+		assert.NotEmpty(t, response.MFAToken)
+
+		var sessions int64
+		common.DB.Model(&entities.Session{}).Count(&sessions)
+		assert.Equal(t, int64(0), sessions)
+
+		if err := mockRedis.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	tests := []struct {
+		name           string
+		request        models.VerifyMFARequest
+		mockBehavior   func(mockRedis redismock.ClientMock)
+		expectedStatus int
+	}{
+		{
+			name:           "Validation error",
+			request:        models.VerifyMFARequest{MFAToken: "challenge"},
+			mockBehavior:   func(mockRedis redismock.ClientMock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "Unknown challenge",
+			request: models.VerifyMFARequest{MFAToken: "unknown", Code: "123456"},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				mockRedis.ExpectHGet("mfa_challenge:"+common.HashToken("unknown"), "user_id").RedisNil()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:    "Locked account",
+			request: models.VerifyMFARequest{MFAToken: "challenge", Code: "123456"},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				mockRedis.ExpectHGet(challengeKey, "user_id").SetVal("1")
+				lockedUntil := strconv.FormatInt(time.Now().Add(10*time.Minute).Unix(), 10)
+				mockRedis.ExpectMGet(lockKeys...).SetVal([]interface{}{lockedUntil, nil})
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:    "Wrong code",
+			request: models.VerifyMFARequest{MFAToken: "challenge", Code: "WRONG-CODES"},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				expectChallenge(mockRedis)
+				mockRedis.ExpectHIncrBy(challengeKey, "attempts", 1).SetVal(1)
+				expectFailure(mockRedis, 2)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:    "Too many wrong codes end the challenge",
+			request: models.VerifyMFARequest{MFAToken: "challenge", Code: "WRONG-CODES"},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				expectChallenge(mockRedis)
+				mockRedis.ExpectHIncrBy(challengeKey, "attempts", 1).SetVal(5)
+				mockRedis.ExpectDel(challengeKey).SetVal(1)
+				expectFailure(mockRedis, 3)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:    "Wrong codes lock the account",
+			request: models.VerifyMFARequest{MFAToken: "challenge", Code: "WRONG-CODES"},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				expectChallenge(mockRedis)
+				mockRedis.ExpectHIncrBy(challengeKey, "attempts", 1).SetVal(1)
+				expectFailure(mockRedis, 5)
+				mockRedis.Regexp().ExpectSet("login_lock:account:mfa@example.com", `\d+`, 15*time.Minute).SetVal("OK")
+				mockRedis.ExpectDel("login_failures:account:mfa@example.com").SetVal(1)
+				mockRedis.Regexp().ExpectSet(`login_unlock:.+`, "mfa@example.com", 15*time.Minute).SetVal("OK")
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:    "Authenticator code",
+			request: models.VerifyMFARequest{MFAToken: "challenge", Code: currentTOTPCode(t, secret)},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				expectChallenge(mockRedis)
+				expectSession(mockRedis)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "Recovery code",
+			request: models.VerifyMFARequest{MFAToken: "challenge", Code: "abcde-fghij"},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				expectChallenge(mockRedis)
+				expectSession(mockRedis)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "Used recovery code",
+			request: models.VerifyMFARequest{MFAToken: "challenge", Code: "ABCDE-FGHIJ"},
+			mockBehavior: func(mockRedis redismock.ClientMock) {
+				expectChallenge(mockRedis)
+				mockRedis.ExpectHIncrBy(challengeKey, "attempts", 1).SetVal(1)
+				expectFailure(mockRedis, 4)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockRedis)
+
+			reqBody, err := json.Marshal(tt.request)
+			assert.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/login/mfa", bytes.NewBuffer(reqBody))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = "10.0.0.1:1234"
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.LoginResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.NotEmpty(t, response.Token)
+				assert.NotEmpty(t, response.RefreshToken)
+			}
+
+			if err := mockRedis.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,694 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	errInvitationAccepted = errors.New("invitation is already accepted")
+	errLastOwner          = errors.New("organization needs an owner")
+)
+
+type OrganizationHandler interface {
+	CreateOrganization(context *gin.Context)
+	ListOrganizations(context *gin.Context)
+	GetOrganization(context *gin.Context)
+	UpdateOrganization(context *gin.Context)
+	DeleteOrganization(context *gin.Context)
+	InviteMember(context *gin.Context)
+	AcceptInvitation(context *gin.Context)
+	UpdateMember(context *gin.Context)
+	RemoveMember(context *gin.Context)
+}
+
+type organizationHandler struct {
+	validate *validator.Validate
+}
+
+func NewOrganizationHandler() OrganizationHandler {
+	return &organizationHandler{
+		validate: validator.New(),
+	}
+}
+
+func (h *organizationHandler) CreateOrganization(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var request models.OrganizationRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	organization := entities.Organization{Name: request.Name}
+
+	// The creator is the first owner
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&organization).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&entities.OrganizationMember{
+			OrganizationID: organization.ID,
+			UserID:         userID.(uint64),
+			Role:           string(models.RoleOwner),
+		}).Error
+	})
+	if err != nil {
+		zap.L().Error("Failed to create organization",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Organization created successfully",
+		zap.Uint64("organization ID", organization.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusCreated, newOrganizationResponse(organization, models.RoleOwner))
+}
+
+func (h *organizationHandler) ListOrganizations(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var rows []struct {
+		entities.Organization
+		Role string
+	}
+	result := common.DB.Model(&entities.Organization{}).
+		Select("organizations.*, organization_members.role").
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.id").
+		Where("organization_members.user_id = ?", userID).
+		Order("organizations.name").
+		Scan(&rows)
+	if result.Error != nil {
+		zap.L().Error("Failed to list organizations",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response := make([]models.OrganizationResponse, 0, len(rows))
+	for _, row := range rows {
+		response = append(response, newOrganizationResponse(row.Organization, models.Role(row.Role)))
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+func (h *organizationHandler) GetOrganization(context *gin.Context) {
+	organization, role, ok := h.authorize(context, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	var rows []struct {
+		entities.OrganizationMember
+		Name  string
+		Email string
+	}
+	result := common.DB.Model(&entities.OrganizationMember{}).
+		Select("organization_members.*, users.name, users.email").
+		Joins("JOIN users ON users.id = organization_members.user_id").
+		Where("organization_members.organization_id = ?", organization.ID).
+		Order("organization_members.created_at").
+		Scan(&rows)
+	if result.Error != nil {
+		zap.L().Error("Failed to list members",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response := models.OrganizationDetailResponse{
+		OrganizationResponse: newOrganizationResponse(organization, role),
+		Members:              make([]models.MemberResponse, 0, len(rows)),
+	}
+	for _, row := range rows {
+		response.Members = append(response.Members, models.MemberResponse{
+			UserID:   row.UserID,
+			Name:     row.Name,
+			Email:    row.Email,
+			Role:     models.Role(row.Role),
+			JoinedAt: row.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+func (h *organizationHandler) UpdateOrganization(context *gin.Context) {
+	organization, role, ok := h.authorize(context, models.RoleAdmin)
+	if !ok {
+		return
+	}
+
+	var request models.OrganizationRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	organization.Name = request.Name
+	result := common.DB.Save(&organization)
+	if result.Error != nil {
+		zap.L().Error("Failed to update organization",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Organization updated successfully",
+		zap.Uint64("organization ID", organization.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, newOrganizationResponse(organization, role))
+}
+
+// DeleteOrganization deletes the organization together with its todos.
+func (h *organizationHandler) DeleteOrganization(context *gin.Context) {
+	organization, _, ok := h.authorize(context, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	result := common.DB.Delete(&organization)
+	if result.Error != nil {
+		zap.L().Error("Failed to delete organization",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Organization deleted successfully",
+		zap.Uint64("organization ID", organization.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Organization deleted successfully"})
+}
+
+func (h *organizationHandler) InviteMember(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	organization, _, ok := h.authorize(context, models.RoleAdmin)
+	if !ok {
+		return
+	}
+
+	var request models.InviteMemberRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	var count int64
+	result := common.DB.Model(&entities.OrganizationMember{}).
+		Joins("JOIN users ON users.id = organization_members.user_id").
+		Where("organization_members.organization_id = ? AND users.email = ?", organization.ID, request.Email).
+		Count(&count)
+	if result.Error != nil {
+		zap.L().Error("Failed to find member",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if count > 0 {
+		zap.L().Error("User is already a member",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusConflict, gin.H{"error": "User is already a member"})
+		return
+	}
+
+	var inviter entities.User
+	if err := common.DB.First(&inviter, userID).Error; err != nil {
+		zap.L().Error("Failed to find inviter",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The invitation is in the language of the invitee if they have an account
+	locale := common.DefaultLocale
+	var invitee entities.User
+	if err := common.DB.Where("email = ?", request.Email).First(&invitee).Error; err == nil {
+		locale = invitee.Locale
+	}
+
+	token, err := common.GenerateSecureToken()
+	if err != nil {
+		zap.L().Error("Failed to generate invitation token",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation := entities.OrganizationInvitation{
+		OrganizationID: organization.ID,
+		Email:          request.Email,
+		Role:           string(request.Role),
+		TokenHash:      common.HashToken(token),
+		InvitedBy:      &inviter.ID,
+		ExpiresAt:      time.Now().Add(invitationTTL),
+	}
+
+	err = common.DB.Transaction(func(tx *gorm.DB) error {
+		// Inviting again replaces the pending invitation
+		err := tx.Where("organization_id = ? AND email = ? AND accepted_at IS NULL", organization.ID, request.Email).
+			Delete(&entities.OrganizationInvitation{}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(&invitation).Error; err != nil {
+			return err
+		}
+
+		return common.EnqueueEvent(tx,
+			models.MemberInvited,
+			strconv.FormatUint(organization.ID, 10),
+			models.MemberInvitedPayload{
+				OrganizationID:   organization.ID,
+				OrganizationName: organization.Name,
+				Email:            invitation.Email,
+				Locale:           locale,
+				InviterName:      inviter.Name,
+				Role:             invitation.Role,
+				Token:            token,
+				ExpiresAt:        invitation.ExpiresAt,
+			},
+		)
+	})
+	if err != nil {
+		zap.L().Error("Failed to invite member",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Member invited",
+		zap.Uint64("organization ID", organization.ID),
+		zap.Uint64("invitation ID", invitation.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusCreated, models.InvitationResponse{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      models.Role(invitation.Role),
+		ExpiresAt: invitation.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// AcceptInvitation makes the user a member. Only the account with the invited
+// email can accept, so a forwarded link is of no use.
+func (h *organizationHandler) AcceptInvitation(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var request models.AcceptInvitationRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	var user entities.User
+	if err := common.DB.First(&user, userID).Error; err != nil {
+		zap.L().Error("Failed to find user",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var invitation entities.OrganizationInvitation
+	result := common.DB.Where("token_hash = ?", common.HashToken(request.Token)).First(&invitation)
+	if result.Error != nil || invitation.AcceptedAt != nil || invitation.ExpiresAt.Before(time.Now()) ||
+		!strings.EqualFold(invitation.Email, user.Email) {
+		zap.L().Error("Invalid invitation",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invitation is invalid or expired"})
+		return
+	}
+
+	var organization entities.Organization
+	result = common.DB.First(&organization, invitation.OrganizationID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		zap.L().Error("Organization of invitation not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invitation is invalid or expired"})
+		return
+	}
+	if result.Error != nil {
+		zap.L().Error("Failed to find organization",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	role, err := common.OrganizationRole(common.DB, invitation.OrganizationID, user.ID)
+	if err != nil {
+		zap.L().Error("Failed to find member",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if role != "" {
+		zap.L().Error("User is already a member",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusConflict, gin.H{"error": "You are already a member"})
+		return
+	}
+
+	err = common.DB.Transaction(func(tx *gorm.DB) error {
+		// Claim the invitation first, so two concurrent requests can't both use it
+		result := tx.Model(&invitation).Where("accepted_at IS NULL").Update("accepted_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvitationAccepted
+		}
+
+		return tx.Create(&entities.OrganizationMember{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         user.ID,
+			Role:           invitation.Role,
+		}).Error
+	})
+	if errors.Is(err, errInvitationAccepted) {
+		zap.L().Error("Invitation is already accepted",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invitation is invalid or expired"})
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to accept invitation",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Invitation accepted",
+		zap.Uint64("organization ID", invitation.OrganizationID),
+		zap.Uint64("user ID", user.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, newOrganizationResponse(organization, models.Role(invitation.Role)))
+}
+
+// UpdateMember changes the role of a member. Only owners can make owners or
+// change the role of an owner.
+func (h *organizationHandler) UpdateMember(context *gin.Context) {
+	organization, role, ok := h.authorize(context, models.RoleAdmin)
+	if !ok {
+		return
+	}
+
+	member, ok := h.findMember(context, organization.ID)
+	if !ok {
+		return
+	}
+
+	var request models.UpdateMemberRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	if (request.Role == models.RoleOwner || models.Role(member.Role) == models.RoleOwner) && role != models.RoleOwner {
+		zap.L().Error("Only owners can manage owners",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusForbidden, gin.H{"error": "Only owners can manage owners"})
+		return
+	}
+
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&member).
+			Where("organization_id = ? AND user_id = ?", member.OrganizationID, member.UserID).
+			Update("role", request.Role).Error
+		if err != nil {
+			return err
+		}
+
+		return ensureOwner(tx, organization.ID)
+	})
+	if !h.memberChanged(context, err) {
+		return
+	}
+
+	zap.L().Info("Member role changed",
+		zap.Uint64("organization ID", organization.ID),
+		zap.Uint64("user ID", member.UserID),
+		zap.String("role", string(request.Role)),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Member updated successfully"})
+}
+
+// RemoveMember removes a member. Members can always leave, removing others
+// needs an admin, and removing an owner needs an owner.
+func (h *organizationHandler) RemoveMember(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	organization, role, ok := h.authorize(context, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	member, ok := h.findMember(context, organization.ID)
+	if !ok {
+		return
+	}
+
+	required := models.RoleAdmin
+	if models.Role(member.Role) == models.RoleOwner {
+		required = models.RoleOwner
+	}
+	if member.UserID != userID && !role.AtLeast(required) {
+		zap.L().Error("User does not have permission to remove this member",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to remove this member"})
+		return
+	}
+
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("organization_id = ? AND user_id = ?", member.OrganizationID, member.UserID).
+			Delete(&entities.OrganizationMember{}).Error
+		if err != nil {
+			return err
+		}
+
+		return ensureOwner(tx, organization.ID)
+	})
+	if !h.memberChanged(context, err) {
+		return
+	}
+
+	zap.L().Info("Member removed",
+		zap.Uint64("organization ID", organization.ID),
+		zap.Uint64("user ID", member.UserID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+func (h *organizationHandler) bind(context *gin.Context, request interface{}) bool {
+	if err := context.ShouldBindJSON(request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// authorize loads the organization of the request and checks the role of the
+// user in it. Organizations of others are reported as not found.
+func (h *organizationHandler) authorize(context *gin.Context, min models.Role) (entities.Organization, models.Role, bool) {
+	userID, _ := context.Get("userID")
+
+	var organization entities.Organization
+
+	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return organization, "", false
+	}
+
+	role, err := common.OrganizationRole(common.DB, id, userID.(uint64))
+	if err == nil && role != "" {
+		err = common.DB.First(&organization, id).Error
+	}
+	if err != nil {
+		zap.L().Error("Failed to find organization",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return organization, "", false
+	}
+
+	if role == "" {
+		zap.L().Error("Organization not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return organization, "", false
+	}
+
+	if !role.AtLeast(min) {
+		zap.L().Error("User does not have permission for this organization",
+			zap.String("url path", context.Request.URL.Path),
+			zap.String("role", string(role)),
+		)
+		context.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission for this organization"})
+		return organization, "", false
+	}
+
+	return organization, role, true
+}
+
+func (h *organizationHandler) findMember(context *gin.Context, organizationID uint64) (entities.OrganizationMember, bool) {
+	var member entities.OrganizationMember
+
+	memberID, err := strconv.ParseUint(context.Param("userId"), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid user ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return member, false
+	}
+
+	result := common.DB.Where("organization_id = ? AND user_id = ?", organizationID, memberID).First(&member)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		zap.L().Error("Member not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return member, false
+	}
+	if result.Error != nil {
+		zap.L().Error("Failed to find member",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return member, false
+	}
+
+	return member, true
+}
+
+// memberChanged answers the errors of changing a member.
+func (h *organizationHandler) memberChanged(context *gin.Context, err error) bool {
+	if errors.Is(err, errLastOwner) {
+		zap.L().Error("Organization would have no owner",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusConflict, gin.H{"error": "An organization needs at least one owner"})
+		return false
+	}
+	if err != nil {
+		zap.L().Error("Failed to change member",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// ensureOwner fails when a change left the organization without an owner.
+func ensureOwner(tx *gorm.DB, organizationID uint64) error {
+	var owners int64
+	err := tx.Model(&entities.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", organizationID, models.RoleOwner).
+		Count(&owners).Error
+	if err != nil {
+		return err
+	}
+
+	if owners == 0 {
+		return errLastOwner
+	}
+
+	return nil
+}
+
+func newOrganizationResponse(organization entities.Organization, role models.Role) models.OrganizationResponse {
+	return models.OrganizationResponse{
+		ID:        organization.ID,
+		Name:      organization.Name,
+		Role:      role,
+		CreatedAt: organization.CreatedAt.Format(time.RFC3339),
+	}
+}
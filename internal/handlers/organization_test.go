@@ -0,0 +1,234 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/internal/handlers"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+// newUserRouter authenticates requests as the user in the X-User header, see
+// serveAs.
+func newUserRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseUint(c.GetHeader("X-User"), 10, 64)
+		c.Set("userID", userID)
+		c.Next()
+	})
+
+	return router
+}
+
+func setupOrganizationRouter() *gin.Engine {
+	organizationHandler := handlers.NewOrganizationHandler()
+
+	router := newUserRouter()
+	router.POST("/organizations", organizationHandler.CreateOrganization)
+	router.GET("/organizations", organizationHandler.ListOrganizations)
+	router.POST("/organizations/invitations/accept", organizationHandler.AcceptInvitation)
+	router.GET("/organizations/:id", organizationHandler.GetOrganization)
+	router.PUT("/organizations/:id", organizationHandler.UpdateOrganization)
+	router.DELETE("/organizations/:id", organizationHandler.DeleteOrganization)
+	router.POST("/organizations/:id/invitations", organizationHandler.InviteMember)
+	router.PUT("/organizations/:id/members/:userId", organizationHandler.UpdateMember)
+	router.DELETE("/organizations/:id/members/:userId", organizationHandler.RemoveMember)
+
+	return router
+}
+
+func serveAs(router *gin.Engine, userID uint64, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reqBody []byte
+	if body != nil {
+		reqBody, _ = json.Marshal(body)
+	}
+
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", strconv.FormatUint(userID, 10))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestCreateAndListOrganizations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	router := setupOrganizationRouter()
+
+	w := serveAs(router, 1, http.MethodPost, "/organizations", models.OrganizationRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serveAs(router, 1, http.MethodPost, "/organizations", models.OrganizationRequest{Name: "Team"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created models.OrganizationResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, models.RoleOwner, created.Role)
+
+	w = serveAs(router, 1, http.MethodGet, "/organizations", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var organizations []models.OrganizationResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &organizations))
+	if assert.Len(t, organizations, 1) {
+		assert.Equal(t, "Team", organizations[0].Name)
+		assert.Equal(t, models.RoleOwner, organizations[0].Role)
+	}
+
+	// Others don't see it
+	w = serveAs(router, 2, http.MethodGet, "/organizations", nil)
+	assert.Equal(t, "[]", w.Body.String())
+
+	w = serveAs(router, 2, http.MethodGet, "/organizations/"+strconv.FormatUint(created.ID, 10), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestOrganizationPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	// User 1 is the owner, 2 an admin, 3 a member and 4 a viewer
+	for id, role := range map[uint64]models.Role{1: models.RoleOwner, 2: models.RoleAdmin, 3: models.RoleMember, 4: models.RoleViewer} {
+		common.DB.Create(&entities.User{ID: id, Email: strconv.FormatUint(id, 10) + "@example.com", Name: "User"})
+		common.DB.Create(&entities.OrganizationMember{OrganizationID: 1, UserID: id, Role: string(role)})
+	}
+	common.DB.Create(&entities.Organization{ID: 1, Name: "Team"})
+
+	router := setupOrganizationRouter()
+
+	tests := []struct {
+		name           string
+		userID         uint64
+		method         string
+		path           string
+		body           interface{}
+		expectedStatus int
+	}{
+		{name: "Viewer Reads", userID: 4, method: http.MethodGet, path: "/organizations/1", expectedStatus: http.StatusOK},
+		{name: "Invalid ID", userID: 4, method: http.MethodGet, path: "/organizations/abc", expectedStatus: http.StatusBadRequest},
+		{name: "Member Renames", userID: 3, method: http.MethodPut, path: "/organizations/1", body: models.OrganizationRequest{Name: "New"}, expectedStatus: http.StatusForbidden},
+		{name: "Admin Renames", userID: 2, method: http.MethodPut, path: "/organizations/1", body: models.OrganizationRequest{Name: "New"}, expectedStatus: http.StatusOK},
+		{name: "Admin Deletes", userID: 2, method: http.MethodDelete, path: "/organizations/1", expectedStatus: http.StatusForbidden},
+		{name: "Admin Promotes To Owner", userID: 2, method: http.MethodPut, path: "/organizations/1/members/3", body: models.UpdateMemberRequest{Role: models.RoleOwner}, expectedStatus: http.StatusForbidden},
+		{name: "Admin Demotes Owner", userID: 2, method: http.MethodPut, path: "/organizations/1/members/1", body: models.UpdateMemberRequest{Role: models.RoleMember}, expectedStatus: http.StatusForbidden},
+		{name: "Admin Changes Member", userID: 2, method: http.MethodPut, path: "/organizations/1/members/3", body: models.UpdateMemberRequest{Role: models.RoleViewer}, expectedStatus: http.StatusOK},
+		{name: "Unknown Member", userID: 2, method: http.MethodPut, path: "/organizations/1/members/9", body: models.UpdateMemberRequest{Role: models.RoleViewer}, expectedStatus: http.StatusNotFound},
+		{name: "Last Owner Demotes Self", userID: 1, method: http.MethodPut, path: "/organizations/1/members/1", body: models.UpdateMemberRequest{Role: models.RoleAdmin}, expectedStatus: http.StatusConflict},
+		{name: "Last Owner Leaves", userID: 1, method: http.MethodDelete, path: "/organizations/1/members/1", expectedStatus: http.StatusConflict},
+		{name: "Viewer Removes Other", userID: 4, method: http.MethodDelete, path: "/organizations/1/members/3", expectedStatus: http.StatusForbidden},
+		{name: "Viewer Leaves", userID: 4, method: http.MethodDelete, path: "/organizations/1/members/4", expectedStatus: http.StatusOK},
+		{name: "Removed Viewer Reads", userID: 4, method: http.MethodGet, path: "/organizations/1", expectedStatus: http.StatusNotFound},
+		{name: "Admin Removes Member", userID: 2, method: http.MethodDelete, path: "/organizations/1/members/3", expectedStatus: http.StatusOK},
+		{name: "Owner Deletes", userID: 1, method: http.MethodDelete, path: "/organizations/1", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(router, tt.userID, tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestInvitations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.User{ID: 1, Email: "owner@example.com", Name: "Owner"})
+	common.DB.Create(&entities.User{ID: 2, Email: "invitee@example.com", Name: "Invitee", Locale: "tr"})
+	common.DB.Create(&entities.User{ID: 3, Email: "other@example.com", Name: "Other"})
+	common.DB.Create(&entities.Organization{ID: 1, Name: "Team"})
+	common.DB.Create(&entities.OrganizationMember{OrganizationID: 1, UserID: 1, Role: string(models.RoleOwner)})
+
+	router := setupOrganizationRouter()
+
+	invite := models.InviteMemberRequest{Email: "invitee@example.com", Role: models.RoleMember}
+
+	w := serveAs(router, 1, http.MethodPost, "/organizations/1/invitations", models.InviteMemberRequest{Email: "invitee@example.com", Role: models.RoleOwner})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serveAs(router, 1, http.MethodPost, "/organizations/1/invitations", models.InviteMemberRequest{Email: "owner@example.com", Role: models.RoleMember})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = serveAs(router, 1, http.MethodPost, "/organizations/1/invitations", invite)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// The invitation is emailed through the outbox, in the language of the invitee
+	var outboxEvent entities.OutboxEvent
+	common.DB.Where("event_type = ?", models.MemberInvited).Last(&outboxEvent)
+
+	var event models.Event
+	assert.NoError(t, json.Unmarshal([]byte(outboxEvent.Value), &event))
+
+	var payload models.MemberInvitedPayload
+	assert.NoError(t, json.Unmarshal(event.Payload, &payload))
+	assert.Equal(t, "invitee@example.com", payload.Email)
+	assert.Equal(t, "tr", payload.Locale)
+	assert.Equal(t, "Team", payload.OrganizationName)
+
+	accept := models.AcceptInvitationRequest{Token: payload.Token}
+
+	expiredAt := time.Now().Add(-time.Hour)
+	common.DB.Create(&entities.OrganizationInvitation{
+		OrganizationID: 1,
+		Email:          "other@example.com",
+		Role:           string(models.RoleViewer),
+		TokenHash:      common.HashToken("expired"),
+		ExpiresAt:      expiredAt,
+	})
+	// Organization 9 doesn't exist anymore
+	common.DB.Create(&entities.OrganizationInvitation{
+		OrganizationID: 9,
+		Email:          "other@example.com",
+		Role:           string(models.RoleViewer),
+		TokenHash:      common.HashToken("orphaned"),
+		ExpiresAt:      time.Now().Add(time.Hour),
+	})
+
+	tests := []struct {
+		name           string
+		userID         uint64
+		token          string
+		expectedStatus int
+	}{
+		{name: "Expired Invitation", userID: 3, token: "expired", expectedStatus: http.StatusBadRequest},
+		{name: "Deleted Organization", userID: 3, token: "orphaned", expectedStatus: http.StatusBadRequest},
+		{name: "Other Account", userID: 3, token: accept.Token, expectedStatus: http.StatusBadRequest},
+		{name: "Successful Acceptance", userID: 2, token: accept.Token, expectedStatus: http.StatusOK},
+		{name: "Already Accepted", userID: 2, token: accept.Token, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(router, tt.userID, http.MethodPost, "/organizations/invitations/accept", models.AcceptInvitationRequest{Token: tt.token})
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	role, err := common.OrganizationRole(common.DB, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleMember, role)
+}
@@ -0,0 +1,212 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/internal/handlers"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+func patchAs(router *gin.Engine, userID uint64, path, contentType, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPatch, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-User", strconv.FormatUint(userID, 10))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestPatchTodo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	todoHandler := handlers.NewTodoHandler()
+
+	router := newUserRouter()
+	router.PATCH("/todo/:id", todoHandler.PatchTodo)
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dueAt := time.Date(2030, 7, 1, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		userID         uint64
+		id             string
+		contentType    string
+		patch          string
+		expectedStatus int
+		check          func(t *testing.T, todo models.TodoResponse)
+	}{
+		{
+			name:           "Merge patch changes one field",
+			userID:         1,
+			id:             "1",
+			contentType:    "application/merge-patch+json",
+			patch:          `{"status": "in_progress"}`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, todo models.TodoResponse) {
+				assert.Equal(t, string(models.InProgress), todo.Status)
+				assert.Equal(t, "Buy groceries", todo.Description)
+				assert.NotEmpty(t, todo.DueAt)
+				assert.Equal(t, createdAt.Format(time.RFC3339), todo.CreatedAt)
+			},
+		},
+		{
+			name:           "Plain JSON is a merge patch",
+			userID:         1,
+			id:             "1",
+			contentType:    "application/json",
+			patch:          `{"description": "Buy vegetables"}`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, todo models.TodoResponse) {
+				assert.Equal(t, "Buy vegetables", todo.Description)
+				assert.Equal(t, string(models.Pending), todo.Status)
+			},
+		},
+		{
+			name:           "Null clears a field",
+			userID:         1,
+			id:             "1",
+			contentType:    "application/merge-patch+json",
+			patch:          `{"due_at": null}`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, todo models.TodoResponse) {
+				assert.Empty(t, todo.DueAt)
+				assert.Equal(t, "Buy groceries", todo.Description)
+			},
+		},
+		{
+			name:           "JSON patch",
+			userID:         1,
+			id:             "1",
+			contentType:    "application/json-patch+json",
+			patch:          `[{"op": "test", "path": "/status", "value": "pending"}, {"op": "replace", "path": "/status", "value": "completed"}, {"op": "remove", "path": "/due_at"}]`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, todo models.TodoResponse) {
+				assert.Equal(t, string(models.Completed), todo.Status)
+				assert.NotEmpty(t, todo.CompletedAt)
+				assert.Empty(t, todo.DueAt)
+			},
+		},
+		{
+			name:           "Failed JSON patch test",
+			userID:         1,
+			id:             "1",
+			contentType:    "application/json-patch+json",
+			patch:          `[{"op": "test", "path": "/status", "value": "completed"}, {"op": "replace", "path": "/description", "value": "Buy nothing"}]`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Invalid JSON patch",
+			userID:         1,
+			id:             "1",
+			contentType:    "application/json-patch+json",
+			patch:          `[{"op": "replace", "path": "/missing/field", "value": 1}]`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid changed field",
+			userID:         1,
+			id:             "1",
+			contentType:    "application/merge-patch+json",
+			patch:          `{"description": "Buy"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Illegal transition",
+			userID:         1,
+			id:             "1",
+			contentType:    "application/merge-patch+json",
+			patch:          `{"status": "archived"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unknown field",
+			userID:         1,
+			id:             "1",
+			contentType:    "application/merge-patch+json",
+			patch:          `{"user_id": 2}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Malformed patch",
+			userID:         1,
+			id:             "1",
+			contentType:    "application/merge-patch+json",
+			patch:          `{"status":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unsupported patch type",
+			userID:         1,
+			id:             "1",
+			contentType:    "text/plain",
+			patch:          `status=completed`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "Other user's todo",
+			userID:         2,
+			id:             "1",
+			contentType:    "application/merge-patch+json",
+			patch:          `{"status": "completed"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Todo Not Found",
+			userID:         1,
+			id:             "4",
+			contentType:    "application/merge-patch+json",
+			patch:          `{"status": "completed"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid ID",
+			userID:         1,
+			id:             "abc",
+			contentType:    "application/merge-patch+json",
+			patch:          `{"status": "completed"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup test database
+			testDB := common.SetupTestDB()
+			common.SetDB(testDB) // Set the mock database for testing
+
+			common.DB.Create(&entities.User{ID: 1, Email: "test@test.com", Name: "Test User"})
+			common.DB.Create(&entities.User{ID: 2, Email: "other@test.com", Name: "Other User"})
+			common.DB.Create(&entities.Todo{ID: 1, Description: "Buy groceries", Status: "pending", UserID: 1, DueAt: &dueAt, CreatedAt: createdAt})
+
+			w := patchAs(router, tt.userID, "/todo/"+tt.id, tt.contentType, tt.patch)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+
+			if tt.check != nil {
+				var todo models.TodoResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todo))
+				tt.check(t, todo)
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				var stored entities.Todo
+				common.DB.First(&stored, 1)
+				assert.Equal(t, "Buy groceries", stored.Description)
+				assert.Equal(t, "pending", stored.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ProjectHandler interface {
+	CreateProject(context *gin.Context)
+	ListProjects(context *gin.Context)
+	GetProject(context *gin.Context)
+	UpdateProject(context *gin.Context)
+	DeleteProject(context *gin.Context)
+}
+
+type projectHandler struct {
+	validate *validator.Validate
+}
+
+func NewProjectHandler() ProjectHandler {
+	return &projectHandler{
+		validate: validator.New(),
+	}
+}
+
+func (h *projectHandler) CreateProject(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var request models.ProjectRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	project := entities.Project{
+		UserID:   userID.(uint64),
+		Name:     request.Name,
+		Color:    request.Color,
+		Archived: request.Archived,
+	}
+
+	if request.Position != nil {
+		project.Position = *request.Position
+	} else {
+		// New projects go last
+		err := common.DB.Model(&entities.Project{}).
+			Select("COALESCE(MAX(position) + 1, 0)").
+			Where("user_id = ?", userID).
+			Scan(&project.Position).Error
+		if err != nil {
+			zap.L().Error("Failed to find project position",
+				zap.String("url path", context.Request.URL.Path),
+				zap.Error(err),
+			)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result := common.DB.Create(&project)
+	if result.Error != nil {
+		zap.L().Error("Failed to create project",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Project created successfully",
+		zap.Uint64("project ID", project.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusCreated, newProjectResponse(project))
+}
+
+// ListProjects lists the active projects of the user, or the archived ones.
+func (h *projectHandler) ListProjects(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var query models.ProjectListQuery
+
+	if err := context.ShouldBindQuery(&query); err != nil {
+		zap.L().Error("Failed to bind query",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var projects []entities.Project
+	result := common.DB.Where("user_id = ? AND archived = ?", userID, query.Archived).
+		Order("position, id").
+		Find(&projects)
+	if result.Error != nil {
+		zap.L().Error("Failed to list projects",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response := make([]models.ProjectResponse, 0, len(projects))
+	for _, project := range projects {
+		response = append(response, newProjectResponse(project))
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+func (h *projectHandler) GetProject(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	project, ok := h.findProject(context, userID.(uint64))
+	if !ok {
+		return
+	}
+
+	context.JSON(http.StatusOK, newProjectResponse(project))
+}
+
+func (h *projectHandler) UpdateProject(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	project, ok := h.findProject(context, userID.(uint64))
+	if !ok {
+		return
+	}
+
+	var request models.ProjectRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	project.Name = request.Name
+	project.Color = request.Color
+	project.Archived = request.Archived
+	if request.Position != nil {
+		project.Position = *request.Position
+	}
+
+	result := common.DB.Save(&project)
+	if result.Error != nil {
+		zap.L().Error("Failed to update project",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Project updated successfully",
+		zap.Uint64("project ID", project.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, newProjectResponse(project))
+}
+
+// DeleteProject deletes a project. Its todos are moved to the inbox, or
+// deleted with it when asked to.
+func (h *projectHandler) DeleteProject(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var query models.DeleteProjectQuery
+
+	if err := context.ShouldBindQuery(&query); err != nil {
+		zap.L().Error("Failed to bind query",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validate.Struct(query); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, ok := h.findProject(context, userID.(uint64))
+	if !ok {
+		return
+	}
+
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		todos := tx.Model(&entities.Todo{}).Where("project_id = ?", project.ID)
+
+		var err error
+		if query.Todos == "delete" {
+			err = todos.Delete(&entities.Todo{}).Error
+		} else {
+			err = todos.Update("project_id", nil).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&project).Error
+	})
+	if err != nil {
+		zap.L().Error("Failed to delete project",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Project deleted successfully",
+		zap.Uint64("project ID", project.ID),
+		zap.String("todos", query.Todos),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+func (h *projectHandler) bind(context *gin.Context, request interface{}) bool {
+	if err := context.ShouldBindJSON(request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// findProject loads a project of the user by the id param. Projects of others
+// are reported as not found.
+func (h *projectHandler) findProject(context *gin.Context, userID uint64) (entities.Project, bool) {
+	var project entities.Project
+
+	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return project, false
+	}
+
+	result := common.DB.Where("id = ? AND user_id = ?", id, userID).First(&project)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		zap.L().Error("Project not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return project, false
+	}
+	if result.Error != nil {
+		zap.L().Error("Failed to find project",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return project, false
+	}
+
+	return project, true
+}
+
+// projectAllowed checks the project a todo is put in and answers with 400
+// otherwise. Only private todos can be in a project, which must be one of
+// their creator. Todos can't be moved into archived projects.
+func projectAllowed(context *gin.Context, projectID, currentProjectID *uint64, ownerID uint64, organizationID *uint64) bool {
+	if projectID == nil {
+		return true
+	}
+
+	if organizationID != nil {
+		zap.L().Error("Todo of an organization put in a project",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Todos of an organization can not be in a project"})
+		return false
+	}
+
+	var project entities.Project
+	result := common.DB.Where("id = ? AND user_id = ?", *projectID, ownerID).First(&project)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		zap.L().Error("Project not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Project not found"})
+		return false
+	}
+	if result.Error != nil {
+		zap.L().Error("Failed to find project",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return false
+	}
+
+	moved := currentProjectID == nil || *currentProjectID != project.ID
+	if project.Archived && moved {
+		zap.L().Error("Todo moved into an archived project",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Project is archived"})
+		return false
+	}
+
+	return true
+}
+
+func newProjectResponse(project entities.Project) models.ProjectResponse {
+	return models.ProjectResponse{
+		ID:        project.ID,
+		Name:      project.Name,
+		Color:     project.Color,
+		Archived:  project.Archived,
+		Position:  project.Position,
+		CreatedAt: project.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: project.UpdatedAt.Format(time.RFC3339),
+	}
+}
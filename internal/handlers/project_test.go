@@ -0,0 +1,163 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/internal/handlers"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+func setupProjectRouter() *gin.Engine {
+	todoHandler := handlers.NewTodoHandler()
+	projectHandler := handlers.NewProjectHandler()
+
+	router := newUserRouter()
+	router.POST("/todo", todoHandler.CreateTodo)
+	router.GET("/todo", todoHandler.ListTodos)
+	router.PUT("/todo/:id", todoHandler.UpdateTodo)
+	router.POST("/projects", projectHandler.CreateProject)
+	router.GET("/projects", projectHandler.ListProjects)
+	router.GET("/projects/:id", projectHandler.GetProject)
+	router.PUT("/projects/:id", projectHandler.UpdateProject)
+	router.DELETE("/projects/:id", projectHandler.DeleteProject)
+
+	return router
+}
+
+func TestManageProjects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	router := setupProjectRouter()
+
+	first := 0
+
+	tests := []struct {
+		name           string
+		userID         uint64
+		method         string
+		path           string
+		body           interface{}
+		expectedStatus int
+	}{
+		{name: "Missing Name", userID: 1, method: http.MethodPost, path: "/projects", body: models.ProjectRequest{}, expectedStatus: http.StatusBadRequest},
+		{name: "Invalid Color", userID: 1, method: http.MethodPost, path: "/projects", body: models.ProjectRequest{Name: "Home", Color: "blue"}, expectedStatus: http.StatusBadRequest},
+		{name: "Color With Alpha", userID: 1, method: http.MethodPost, path: "/projects", body: models.ProjectRequest{Name: "Home", Color: "#0000ff80"}, expectedStatus: http.StatusBadRequest},
+		{name: "Create First", userID: 1, method: http.MethodPost, path: "/projects", body: models.ProjectRequest{Name: "Home"}, expectedStatus: http.StatusCreated},
+		{name: "Create Second", userID: 1, method: http.MethodPost, path: "/projects", body: models.ProjectRequest{Name: "Work", Color: "#0000ff"}, expectedStatus: http.StatusCreated},
+		{name: "Create Archived", userID: 1, method: http.MethodPost, path: "/projects", body: models.ProjectRequest{Name: "Old", Archived: true}, expectedStatus: http.StatusCreated},
+		{name: "Move To Front", userID: 1, method: http.MethodPut, path: "/projects/2", body: models.ProjectRequest{Name: "Office", Color: "#0000ff", Position: &first}, expectedStatus: http.StatusOK},
+		{name: "Get Project Of Other User", userID: 2, method: http.MethodGet, path: "/projects/1", expectedStatus: http.StatusNotFound},
+		{name: "Update Project Of Other User", userID: 2, method: http.MethodPut, path: "/projects/1", body: models.ProjectRequest{Name: "Mine"}, expectedStatus: http.StatusNotFound},
+		{name: "Invalid ID", userID: 1, method: http.MethodGet, path: "/projects/abc", expectedStatus: http.StatusBadRequest},
+		{name: "Invalid Delete Mode", userID: 1, method: http.MethodDelete, path: "/projects/1?todos=archive", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(router, tt.userID, tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	listNames := func(path string) []string {
+		w := serveAs(router, 1, http.MethodGet, path, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var projects []models.ProjectResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &projects))
+
+		names := []string{}
+		for _, project := range projects {
+			names = append(names, project.Name)
+		}
+		return names
+	}
+
+	// Home was created at position 0 before Office moved there, ties go by id
+	assert.Equal(t, []string{"Home", "Office"}, listNames("/projects"))
+	assert.Equal(t, []string{"Old"}, listNames("/projects?archived=true"))
+}
+
+func TestProjectTodos(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	organizationID := uint64(1)
+	common.DB.Create(&entities.Organization{ID: organizationID, Name: "Team"})
+	common.DB.Create(&entities.OrganizationMember{OrganizationID: organizationID, UserID: 1, Role: string(models.RoleOwner)})
+	common.DB.Create(&entities.Project{ID: 1, UserID: 1, Name: "Home"})
+	common.DB.Create(&entities.Project{ID: 2, UserID: 1, Name: "Work"})
+	common.DB.Create(&entities.Project{ID: 3, UserID: 1, Name: "Old", Archived: true})
+	common.DB.Create(&entities.Project{ID: 4, UserID: 2, Name: "Other"})
+
+	router := setupProjectRouter()
+
+	home, work, archived, other := uint64(1), uint64(2), uint64(3), uint64(4)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           interface{}
+		expectedStatus int
+	}{
+		{name: "Create In Project", method: http.MethodPost, path: "/todo", body: models.TodoRequest{Description: "Water plants", ProjectID: &home}, expectedStatus: http.StatusOK},
+		{name: "Create In Inbox", method: http.MethodPost, path: "/todo", body: models.TodoRequest{Description: "Call mom"}, expectedStatus: http.StatusOK},
+		{name: "Create In Project Of Other User", method: http.MethodPost, path: "/todo", body: models.TodoRequest{Description: "Water plants", ProjectID: &other}, expectedStatus: http.StatusBadRequest},
+		{name: "Create In Archived Project", method: http.MethodPost, path: "/todo", body: models.TodoRequest{Description: "Water plants", ProjectID: &archived}, expectedStatus: http.StatusBadRequest},
+		{name: "Create Organization Todo In Project", method: http.MethodPost, path: "/todo", body: models.TodoRequest{Description: "Team meeting", OrganizationID: &organizationID, ProjectID: &home}, expectedStatus: http.StatusBadRequest},
+		{name: "Move Between Projects", method: http.MethodPut, path: "/todo/1", body: models.TodoUpdateRequest{Description: "Water plants", Status: models.Pending, ProjectID: &work}, expectedStatus: http.StatusOK},
+		{name: "Move To Archived Project", method: http.MethodPut, path: "/todo/2", body: models.TodoUpdateRequest{Description: "Call mom", Status: models.Pending, ProjectID: &archived}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(router, 1, tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	listIDs := func(path string) []uint64 {
+		w := serveAs(router, 1, http.MethodGet, path, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response models.TodoListResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		ids := []uint64{}
+		for _, item := range response.Items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []uint64{1}, listIDs("/todo?project_id=2"))
+	assert.Equal(t, []uint64{}, listIDs("/todo?project_id=1"))
+	assert.Equal(t, []uint64{2}, listIDs("/todo?project_id=0"))
+
+	// Deleting moves the todos to the inbox by default
+	w := serveAs(router, 1, http.MethodDelete, "/projects/2", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.ElementsMatch(t, []uint64{1, 2}, listIDs("/todo?project_id=0"))
+
+	// Or deletes them along
+	w = serveAs(router, 1, http.MethodPut, "/todo/1", models.TodoUpdateRequest{Description: "Water plants", Status: models.Pending, ProjectID: &home})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveAs(router, 1, http.MethodDelete, "/projects/1?todos=delete", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []uint64{2}, listIDs("/todo"))
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+)
+
+type SessionHandler interface {
+	ListSessions(context *gin.Context)
+	RevokeSession(context *gin.Context)
+	RevokeAllSessions(context *gin.Context)
+}
+
+type sessionHandler struct{}
+
+func NewSessionHandler() SessionHandler {
+	return &sessionHandler{}
+}
+
+func (h *sessionHandler) ListSessions(context *gin.Context) {
+	userID, _ := context.Get("userID")
+	currentSessionID := context.GetString("sessionID")
+
+	var sessions []entities.Session
+	result := common.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions)
+	if result.Error != nil {
+		zap.L().Error("Failed to list sessions",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, models.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			Current:    session.ID == currentSessionID,
+			CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+			LastSeenAt: session.LastSeenAt.Format(time.RFC3339),
+		})
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+func (h *sessionHandler) RevokeSession(context *gin.Context) {
+	userID, _ := context.Get("userID")
+	sessionID := context.Param("id")
+
+	found, err := common.RevokeSession(context, common.DB, userID.(uint64), sessionID)
+	if err != nil {
+		zap.L().Error("Failed to revoke session",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !found {
+		zap.L().Error("Session not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	zap.L().Info("Session revoked",
+		zap.Uint64("user ID", userID.(uint64)),
+		zap.String("session ID", sessionID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+func (h *sessionHandler) RevokeAllSessions(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	err := common.RevokeAllSessions(context, common.DB, userID.(uint64))
+	if err != nil {
+		zap.L().Error("Failed to revoke sessions",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("All sessions revoked",
+		zap.Uint64("user ID", userID.(uint64)),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Logged out from all sessions"})
+}
@@ -0,0 +1,201 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/internal/handlers"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+func setupSessions() {
+	revokedAt := time.Now()
+
+	common.DB.Create(&entities.Session{
+		ID:         "current",
+		UserID:     1,
+		UserAgent:  "Firefox",
+		IPAddress:  "10.0.0.1",
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(time.Hour),
+	})
+	common.DB.Create(&entities.Session{
+		ID:         "phone",
+		UserID:     1,
+		UserAgent:  "Android",
+		IPAddress:  "10.0.0.2",
+		LastSeenAt: time.Now().Add(-time.Hour),
+		ExpiresAt:  time.Now().Add(time.Hour),
+	})
+	common.DB.Create(&entities.Session{ID: "revoked", UserID: 1, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt})
+	common.DB.Create(&entities.Session{ID: "expired", UserID: 1, ExpiresAt: time.Now().Add(-time.Hour)})
+	common.DB.Create(&entities.Session{ID: "other", UserID: 2, ExpiresAt: time.Now().Add(time.Hour)})
+}
+
+func TestListSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+	setupSessions()
+
+	sessionHandler := handlers.NewSessionHandler()
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint64(1))
+		c.Set("sessionID", "current")
+		c.Next()
+	})
+	router.GET("/sessions", sessionHandler.ListSessions)
+
+	req, err := http.NewRequest(http.MethodGet, "/sessions", nil)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response []models.SessionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	// Only active sessions of the user, most recently used first
+	if assert.Len(t, response, 2) {
+		assert.Equal(t, "current", response[0].ID)
+		assert.True(t, response[0].Current)
+		assert.Equal(t, "Firefox", response[0].UserAgent)
+		assert.Equal(t, "phone", response[1].ID)
+		assert.False(t, response[1].Current)
+		assert.Equal(t, "10.0.0.2", response[1].IPAddress)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+	setupSessions()
+
+	// Mock Redis setup
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+	mockRedis.ExpectSet("revoked_session:phone", "revoked", 15*time.Minute).SetVal("OK")
+
+	sessionHandler := handlers.NewSessionHandler()
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint64(1))
+		c.Next()
+	})
+	router.DELETE("/sessions/:id", sessionHandler.RevokeSession)
+
+	tests := []struct {
+		name           string
+		sessionID      string
+		expectedStatus int
+	}{
+		{
+			name:           "Successfully revoked",
+			sessionID:      "phone",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Already revoked",
+			sessionID:      "revoked",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Session of another user",
+			sessionID:      "other",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Session not found",
+			sessionID:      "unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodDelete, "/sessions/"+tt.sessionID, nil)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	var session entities.Session
+	common.DB.First(&session, "id = ?", "phone")
+	assert.NotNil(t, session.RevokedAt)
+
+	var other entities.Session
+	common.DB.First(&other, "id = ?", "other")
+	assert.Nil(t, other.RevokedAt)
+
+	if err := mockRedis.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+	setupSessions()
+
+	// Mock Redis setup
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+	mockRedis.ExpectSet("revoked_session:current", "revoked", 15*time.Minute).SetVal("OK")
+	mockRedis.ExpectSet("revoked_session:phone", "revoked", 15*time.Minute).SetVal("OK")
+	mockRedis.ExpectSet("revoked_session:expired", "revoked", 15*time.Minute).SetVal("OK")
+	mockRedis.ExpectSMembers("user_sessions:1").SetVal([]string{"token1", "token2"})
+	mockRedis.ExpectDel("token1", "token2", "user_sessions:1").SetVal(3)
+
+	sessionHandler := handlers.NewSessionHandler()
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint64(1))
+		c.Next()
+	})
+	router.DELETE("/sessions", sessionHandler.RevokeAllSessions)
+
+	req, err := http.NewRequest(http.MethodDelete, "/sessions", nil)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var active int64
+	common.DB.Model(&entities.Session{}).Where("user_id = ? AND revoked_at IS NULL", 1).Count(&active)
+	assert.Equal(t, int64(0), active)
+
+	common.DB.Model(&entities.Session{}).Where("user_id = ? AND revoked_at IS NULL", 2).Count(&active)
+	assert.Equal(t, int64(1), active)
+
+	if err := mockRedis.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
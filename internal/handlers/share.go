@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShareHandler interface {
+	ShareTodo(context *gin.Context)
+	ListShares(context *gin.Context)
+	RevokeShare(context *gin.Context)
+}
+
+type shareHandler struct {
+	validate *validator.Validate
+}
+
+func NewShareHandler() ShareHandler {
+	return &shareHandler{
+		validate: validator.New(),
+	}
+}
+
+// ShareTodo shares a todo with another user, or changes the permission of an
+// existing share. Only the creator of a todo can share it.
+func (h *shareHandler) ShareTodo(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	todo, ok := h.findOwnTodo(context, userID.(uint64))
+	if !ok {
+		return
+	}
+
+	// Organization todos are only accessible through organization roles
+	if todo.OrganizationID != nil {
+		zap.L().Error("Organization todos can't be shared",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Organization todos can't be shared"})
+		return
+	}
+
+	var request models.ShareTodoRequest
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var grantee entities.User
+	result := common.DB.Where("email = ?", request.Email).First(&grantee)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		zap.L().Error("User not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if result.Error != nil {
+		zap.L().Error("Failed to find user",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if grantee.ID == todo.UserID {
+		zap.L().Error("Todo shared with its creator",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "You can not share a todo with yourself"})
+		return
+	}
+
+	share := entities.TodoShare{
+		TodoID:     todo.ID,
+		UserID:     grantee.ID,
+		Permission: string(request.Permission),
+		CreatedAt:  time.Now(),
+	}
+
+	result = common.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "todo_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"permission"}),
+	}).Create(&share)
+	if result.Error != nil {
+		zap.L().Error("Failed to share todo",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Todo shared successfully",
+		zap.Uint64("todo ID", todo.ID),
+		zap.Uint64("user ID", grantee.ID),
+		zap.String("permission", share.Permission),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, newTodoShareResponse(share, grantee))
+}
+
+func (h *shareHandler) ListShares(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	todo, ok := h.findOwnTodo(context, userID.(uint64))
+	if !ok {
+		return
+	}
+
+	var rows []struct {
+		entities.TodoShare
+		Name  string
+		Email string
+	}
+	result := common.DB.Model(&entities.TodoShare{}).
+		Select("todo_shares.*, users.name, users.email").
+		Joins("JOIN users ON users.id = todo_shares.user_id").
+		Where("todo_shares.todo_id = ?", todo.ID).
+		Order("todo_shares.created_at").
+		Scan(&rows)
+	if result.Error != nil {
+		zap.L().Error("Failed to list todo shares",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response := make([]models.TodoShareResponse, 0, len(rows))
+	for _, row := range rows {
+		response = append(response, newTodoShareResponse(row.TodoShare, entities.User{
+			ID:    row.UserID,
+			Name:  row.Name,
+			Email: row.Email,
+		}))
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+// RevokeShare removes a share. The creator of the todo can revoke any share,
+// and users can give up the todos shared with them.
+func (h *shareHandler) RevokeShare(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	todoID, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	granteeID, err := strconv.ParseUint(context.Param("userId"), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid user ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if granteeID != userID {
+		if _, ok := h.findOwnTodo(context, userID.(uint64)); !ok {
+			return
+		}
+	}
+
+	result := common.DB.Where("todo_id = ? AND user_id = ?", todoID, granteeID).Delete(&entities.TodoShare{})
+	if result.Error != nil {
+		zap.L().Error("Failed to revoke todo share",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		zap.L().Error("Todo share not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+		return
+	}
+
+	zap.L().Info("Todo share revoked successfully",
+		zap.Uint64("todo ID", todoID),
+		zap.Uint64("user ID", granteeID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Share revoked successfully"})
+}
+
+// findOwnTodo loads the todo of the request, which must have been created by
+// the user.
+func (h *shareHandler) findOwnTodo(context *gin.Context, userID uint64) (entities.Todo, bool) {
+	var todo entities.Todo
+
+	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return todo, false
+	}
+
+	result := common.DB.First(&todo, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		zap.L().Error("Todo not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return todo, false
+	}
+	if result.Error != nil {
+		zap.L().Error("Failed to find todo",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return todo, false
+	}
+
+	if todo.UserID != userID {
+		zap.L().Error("User does not have permission to share this todo",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusForbidden, gin.H{"error": "Only the creator of a todo can share it"})
+		return todo, false
+	}
+
+	return todo, true
+}
+
+func newTodoShareResponse(share entities.TodoShare, user entities.User) models.TodoShareResponse {
+	return models.TodoShareResponse{
+		UserID:     user.ID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Permission: models.SharePermission(share.Permission),
+		CreatedAt:  share.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,170 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/internal/handlers"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+func setupShareRouter() *gin.Engine {
+	todoHandler := handlers.NewTodoHandler()
+	shareHandler := handlers.NewShareHandler()
+
+	router := newUserRouter()
+	router.GET("/todo", todoHandler.ListTodos)
+	router.GET("/todo/:id", todoHandler.ReadTodo)
+	router.PUT("/todo/:id", todoHandler.UpdateTodo)
+	router.DELETE("/todo/:id", todoHandler.DeleteTodo)
+	router.GET("/todo/:id/shares", shareHandler.ListShares)
+	router.PUT("/todo/:id/shares", shareHandler.ShareTodo)
+	router.DELETE("/todo/:id/shares/:userId", shareHandler.RevokeShare)
+
+	return router
+}
+
+func TestShareTodo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	organizationID := uint64(1)
+
+	common.DB.Create(&entities.User{ID: 1, Email: "owner@example.com", Name: "Owner"})
+	common.DB.Create(&entities.User{ID: 2, Email: "friend@example.com", Name: "Friend"})
+	common.DB.Create(&entities.Todo{ID: 1, Description: "Private Todo", Status: "pending", UserID: 1})
+	common.DB.Create(&entities.Todo{ID: 2, Description: "Organization Todo", Status: "pending", UserID: 1, OrganizationID: &organizationID})
+
+	router := setupShareRouter()
+
+	tests := []struct {
+		name           string
+		userID         uint64
+		path           string
+		body           models.ShareTodoRequest
+		expectedStatus int
+	}{
+		{name: "Invalid Permission", userID: 1, path: "/todo/1/shares", body: models.ShareTodoRequest{Email: "friend@example.com", Permission: "delete"}, expectedStatus: http.StatusBadRequest},
+		{name: "Unknown User", userID: 1, path: "/todo/1/shares", body: models.ShareTodoRequest{Email: "nobody@example.com", Permission: models.ShareRead}, expectedStatus: http.StatusNotFound},
+		{name: "Share With Self", userID: 1, path: "/todo/1/shares", body: models.ShareTodoRequest{Email: "owner@example.com", Permission: models.ShareRead}, expectedStatus: http.StatusBadRequest},
+		{name: "Not The Creator", userID: 2, path: "/todo/1/shares", body: models.ShareTodoRequest{Email: "owner@example.com", Permission: models.ShareRead}, expectedStatus: http.StatusForbidden},
+		{name: "Todo Not Found", userID: 1, path: "/todo/9/shares", body: models.ShareTodoRequest{Email: "friend@example.com", Permission: models.ShareRead}, expectedStatus: http.StatusNotFound},
+		{name: "Organization Todo", userID: 1, path: "/todo/2/shares", body: models.ShareTodoRequest{Email: "friend@example.com", Permission: models.ShareRead}, expectedStatus: http.StatusBadRequest},
+		{name: "Successful Share", userID: 1, path: "/todo/1/shares", body: models.ShareTodoRequest{Email: "friend@example.com", Permission: models.ShareRead}, expectedStatus: http.StatusOK},
+		{name: "Change Permission", userID: 1, path: "/todo/1/shares", body: models.ShareTodoRequest{Email: "friend@example.com", Permission: models.ShareEdit}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(router, tt.userID, http.MethodPut, tt.path, tt.body)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	w := serveAs(router, 1, http.MethodGet, "/todo/1/shares", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var shares []models.TodoShareResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &shares))
+	if assert.Len(t, shares, 1) {
+		assert.Equal(t, uint64(2), shares[0].UserID)
+		assert.Equal(t, "friend@example.com", shares[0].Email)
+		assert.Equal(t, models.ShareEdit, shares[0].Permission)
+	}
+
+	w = serveAs(router, 2, http.MethodGet, "/todo/1/shares", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestSharedTodoPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	// Todo 1 is shared with user 2 to read and with user 3 to edit, user 4
+	// has no access. Shares of organization todo 3 are ignored.
+	organizationID := uint64(1)
+	common.DB.Create(&entities.Todo{ID: 1, Description: "Shared Todo", Status: "pending", UserID: 1})
+	common.DB.Create(&entities.Todo{ID: 2, Description: "Private Todo", Status: "pending", UserID: 1})
+	common.DB.Create(&entities.Todo{ID: 3, Description: "Organization Todo", Status: "pending", UserID: 1, OrganizationID: &organizationID})
+	common.DB.Create(&entities.TodoShare{TodoID: 3, UserID: 4, Permission: string(models.ShareEdit)})
+	common.DB.Create(&entities.TodoShare{TodoID: 1, UserID: 2, Permission: string(models.ShareRead)})
+	common.DB.Create(&entities.TodoShare{TodoID: 1, UserID: 3, Permission: string(models.ShareEdit)})
+
+	router := setupShareRouter()
+
+	update := models.TodoUpdateRequest{Description: "Updated Todo", Status: models.InProgress}
+
+	tests := []struct {
+		name           string
+		userID         uint64
+		method         string
+		path           string
+		body           interface{}
+		expectedStatus int
+	}{
+		{name: "Reader Reads", userID: 2, method: http.MethodGet, path: "/todo/1", expectedStatus: http.StatusOK},
+		{name: "Reader Updates", userID: 2, method: http.MethodPut, path: "/todo/1", body: update, expectedStatus: http.StatusForbidden},
+		{name: "Editor Updates", userID: 3, method: http.MethodPut, path: "/todo/1", body: update, expectedStatus: http.StatusOK},
+		{name: "Editor Deletes", userID: 3, method: http.MethodDelete, path: "/todo/1", expectedStatus: http.StatusForbidden},
+		{name: "Reader Reads Other Todo", userID: 2, method: http.MethodGet, path: "/todo/2", expectedStatus: http.StatusForbidden},
+		{name: "Outsider Reads", userID: 4, method: http.MethodGet, path: "/todo/1", expectedStatus: http.StatusForbidden},
+		{name: "Shared Organization Todo", userID: 4, method: http.MethodGet, path: "/todo/3", expectedStatus: http.StatusForbidden},
+		{name: "Shared With Organization", userID: 2, method: http.MethodGet, path: "/todo?shared=true&organization_id=1", expectedStatus: http.StatusBadRequest},
+		{name: "Outsider Revokes", userID: 4, method: http.MethodDelete, path: "/todo/1/shares/2", expectedStatus: http.StatusForbidden},
+		{name: "Reader Leaves", userID: 2, method: http.MethodDelete, path: "/todo/1/shares/2", expectedStatus: http.StatusOK},
+		{name: "Former Reader Reads", userID: 2, method: http.MethodGet, path: "/todo/1", expectedStatus: http.StatusForbidden},
+		{name: "Revoke Missing Share", userID: 1, method: http.MethodDelete, path: "/todo/1/shares/2", expectedStatus: http.StatusNotFound},
+		{name: "Creator Revokes", userID: 1, method: http.MethodDelete, path: "/todo/1/shares/3", expectedStatus: http.StatusOK},
+		{name: "Former Editor Updates", userID: 3, method: http.MethodPut, path: "/todo/1", body: update, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(router, tt.userID, tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestListSharedTodos(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.Todo{ID: 1, Description: "Shared Todo", Status: "pending", UserID: 1})
+	common.DB.Create(&entities.Todo{ID: 2, Description: "Private Todo", Status: "pending", UserID: 1})
+	common.DB.Create(&entities.Todo{ID: 3, Description: "Own Todo", Status: "pending", UserID: 2})
+	common.DB.Create(&entities.TodoShare{TodoID: 1, UserID: 2, Permission: string(models.ShareRead)})
+
+	router := setupShareRouter()
+
+	w := serveAs(router, 2, http.MethodGet, "/todo?shared=true", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.TodoListResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Items, 1) {
+		assert.Equal(t, uint64(1), response.Items[0].ID)
+		assert.Equal(t, uint64(1), response.Items[0].UserID)
+	}
+
+	// Shared todos aren't mixed into the own todos
+	w = serveAs(router, 2, http.MethodGet, "/todo", nil)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Items, 1) {
+		assert.Equal(t, uint64(3), response.Items[0].ID)
+	}
+}
@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagHandler interface {
+	CreateTag(context *gin.Context)
+	ListTags(context *gin.Context)
+	UpdateTag(context *gin.Context)
+	DeleteTag(context *gin.Context)
+	AttachTag(context *gin.Context)
+	DetachTag(context *gin.Context)
+}
+
+type tagHandler struct {
+	validate *validator.Validate
+}
+
+func NewTagHandler() TagHandler {
+	return &tagHandler{
+		validate: validator.New(),
+	}
+}
+
+func (h *tagHandler) CreateTag(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var request models.TagRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	if !h.nameAvailable(context, userID.(uint64), request.Name, 0) {
+		return
+	}
+
+	tag := entities.Tag{
+		UserID: userID.(uint64),
+		Name:   request.Name,
+		Color:  request.Color,
+	}
+
+	result := common.DB.Create(&tag)
+	if result.Error != nil {
+		zap.L().Error("Failed to create tag",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Tag created successfully",
+		zap.Uint64("tag ID", tag.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusCreated, newTagResponse(tag))
+}
+
+func (h *tagHandler) ListTags(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var tags []entities.Tag
+	result := common.DB.Where("user_id = ?", userID).Order("name").Find(&tags)
+	if result.Error != nil {
+		zap.L().Error("Failed to list tags",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response := make([]models.TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		response = append(response, newTagResponse(tag))
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+// UpdateTag renames or recolors a tag. Todos refer to tags by id, so the
+// change shows up on all of them.
+func (h *tagHandler) UpdateTag(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	tag, ok := h.findTag(context, userID.(uint64), "id")
+	if !ok {
+		return
+	}
+
+	var request models.TagRequest
+	if !h.bind(context, &request) {
+		return
+	}
+
+	if !h.nameAvailable(context, userID.(uint64), request.Name, tag.ID) {
+		return
+	}
+
+	tag.Name = request.Name
+	tag.Color = request.Color
+
+	result := common.DB.Save(&tag)
+	if result.Error != nil {
+		zap.L().Error("Failed to update tag",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Tag updated successfully",
+		zap.Uint64("tag ID", tag.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, newTagResponse(tag))
+}
+
+// DeleteTag deletes a tag and removes it from all todos.
+func (h *tagHandler) DeleteTag(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	tag, ok := h.findTag(context, userID.(uint64), "id")
+	if !ok {
+		return
+	}
+
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&entities.TodoTag{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		zap.L().Error("Failed to delete tag",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Tag deleted successfully",
+		zap.Uint64("tag ID", tag.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
+
+// AttachTag puts a tag on a todo. Tags are personal, so reading the todo is
+// enough to label it.
+func (h *tagHandler) AttachTag(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	todo, tag, ok := h.findTodoAndTag(context, userID.(uint64))
+	if !ok {
+		return
+	}
+
+	result := common.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entities.TodoTag{TodoID: todo.ID, TagID: tag.ID})
+	if result.Error != nil {
+		zap.L().Error("Failed to attach tag",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Tag attached successfully",
+		zap.Uint64("todo ID", todo.ID),
+		zap.Uint64("tag ID", tag.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Tag attached successfully"})
+}
+
+func (h *tagHandler) DetachTag(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	todo, tag, ok := h.findTodoAndTag(context, userID.(uint64))
+	if !ok {
+		return
+	}
+
+	result := common.DB.Where("todo_id = ? AND tag_id = ?", todo.ID, tag.ID).Delete(&entities.TodoTag{})
+	if result.Error != nil {
+		zap.L().Error("Failed to detach tag",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Tag detached successfully",
+		zap.Uint64("todo ID", todo.ID),
+		zap.Uint64("tag ID", tag.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Tag detached successfully"})
+}
+
+func (h *tagHandler) bind(context *gin.Context, request interface{}) bool {
+	if err := context.ShouldBindJSON(request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// nameAvailable answers with 409 when the user has another tag of the name.
+func (h *tagHandler) nameAvailable(context *gin.Context, userID uint64, name string, exceptTagID uint64) bool {
+	var count int64
+	err := common.DB.Model(&entities.Tag{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptTagID).
+		Count(&count).Error
+	if err != nil {
+		zap.L().Error("Failed to check tag name",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if count > 0 {
+		zap.L().Error("Tag name already exists",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusConflict, gin.H{"error": "A tag with this name already exists"})
+		return false
+	}
+
+	return true
+}
+
+// findTag loads a tag of the user by the id in the param. Tags of others are
+// reported as not found.
+func (h *tagHandler) findTag(context *gin.Context, userID uint64, param string) (entities.Tag, bool) {
+	var tag entities.Tag
+
+	id, err := strconv.ParseUint(context.Param(param), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid tag ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return tag, false
+	}
+
+	result := common.DB.Where("id = ? AND user_id = ?", id, userID).First(&tag)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		zap.L().Error("Tag not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return tag, false
+	}
+	if result.Error != nil {
+		zap.L().Error("Failed to find tag",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return tag, false
+	}
+
+	return tag, true
+}
+
+func (h *tagHandler) findTodoAndTag(context *gin.Context, userID uint64) (entities.Todo, entities.Tag, bool) {
+	var todo entities.Todo
+
+	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return todo, entities.Tag{}, false
+	}
+
+	result := common.DB.First(&todo, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		zap.L().Error("Todo not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return todo, entities.Tag{}, false
+	}
+	if result.Error != nil {
+		zap.L().Error("Failed to find todo",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return todo, entities.Tag{}, false
+	}
+
+	if !todoAllowed(context, todo, userID, todoRead) {
+		return todo, entities.Tag{}, false
+	}
+
+	tag, ok := h.findTag(context, userID, "tagId")
+
+	return todo, tag, ok
+}
+
+// loadTodoTags fills in the tags the user put on the todos.
+func loadTodoTags(userID uint64, responses ...*models.TodoResponse) error {
+	if len(responses) == 0 {
+		return nil
+	}
+
+	todoIDs := make([]uint64, 0, len(responses))
+	for _, response := range responses {
+		todoIDs = append(todoIDs, response.ID)
+	}
+
+	var rows []struct {
+		TodoID uint64
+		entities.Tag
+	}
+	err := common.DB.Model(&entities.Tag{}).
+		Select("todo_tags.todo_id, tags.*").
+		Joins("JOIN todo_tags ON todo_tags.tag_id = tags.id").
+		Where("tags.user_id = ? AND todo_tags.todo_id IN ?", userID, todoIDs).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	tags := make(map[uint64][]models.TagResponse)
+	for _, row := range rows {
+		tags[row.TodoID] = append(tags[row.TodoID], newTagResponse(row.Tag))
+	}
+
+	for _, response := range responses {
+		if todoTags, ok := tags[response.ID]; ok {
+			response.Tags = todoTags
+		}
+	}
+
+	return nil
+}
+
+// applyTagFilter keeps the todos with any, or all, of the given tags of the
+// user.
+func applyTagFilter(db *gorm.DB, query models.TodoListQuery, userID uint64) *gorm.DB {
+	if len(query.TagIDs) == 0 {
+		return db
+	}
+
+	tagIDs := make(map[uint64]struct{}, len(query.TagIDs))
+	for _, tagID := range query.TagIDs {
+		tagIDs[tagID] = struct{}{}
+	}
+
+	tagged := common.DB.Model(&entities.TodoTag{}).
+		Select("todo_tags.todo_id").
+		Joins("JOIN tags ON tags.id = todo_tags.tag_id").
+		Where("tags.user_id = ? AND todo_tags.tag_id IN ?", userID, query.TagIDs)
+	if query.TagMode == "all" {
+		tagged = tagged.Group("todo_tags.todo_id").Having("COUNT(*) = ?", len(tagIDs))
+	}
+
+	return db.Where("id IN (?)", tagged)
+}
+
+func newTagResponse(tag entities.Tag) models.TagResponse {
+	return models.TagResponse{
+		ID:    tag.ID,
+		Name:  tag.Name,
+		Color: tag.Color,
+	}
+}
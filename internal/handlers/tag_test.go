@@ -0,0 +1,169 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/internal/handlers"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+func setupTagRouter() *gin.Engine {
+	todoHandler := handlers.NewTodoHandler()
+	tagHandler := handlers.NewTagHandler()
+
+	router := newUserRouter()
+	router.GET("/todo", todoHandler.ListTodos)
+	router.GET("/todo/:id", todoHandler.ReadTodo)
+	router.PUT("/todo/:id/tags/:tagId", tagHandler.AttachTag)
+	router.DELETE("/todo/:id/tags/:tagId", tagHandler.DetachTag)
+	router.POST("/tags", tagHandler.CreateTag)
+	router.GET("/tags", tagHandler.ListTags)
+	router.PUT("/tags/:id", tagHandler.UpdateTag)
+	router.DELETE("/tags/:id", tagHandler.DeleteTag)
+
+	return router
+}
+
+func TestManageTags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.Tag{ID: 1, UserID: 1, Name: "work"})
+	common.DB.Create(&entities.Tag{ID: 2, UserID: 2, Name: "home"})
+
+	router := setupTagRouter()
+
+	tests := []struct {
+		name           string
+		userID         uint64
+		method         string
+		path           string
+		body           interface{}
+		expectedStatus int
+	}{
+		{name: "Missing Name", userID: 1, method: http.MethodPost, path: "/tags", body: models.TagRequest{}, expectedStatus: http.StatusBadRequest},
+		{name: "Invalid Color", userID: 1, method: http.MethodPost, path: "/tags", body: models.TagRequest{Name: "home", Color: "orange"}, expectedStatus: http.StatusBadRequest},
+		{name: "Color With Alpha", userID: 1, method: http.MethodPost, path: "/tags", body: models.TagRequest{Name: "home", Color: "#ff880080"}, expectedStatus: http.StatusBadRequest},
+		{name: "Duplicate Name", userID: 1, method: http.MethodPost, path: "/tags", body: models.TagRequest{Name: "work"}, expectedStatus: http.StatusConflict},
+		{name: "Name Of Other User", userID: 1, method: http.MethodPost, path: "/tags", body: models.TagRequest{Name: "home", Color: "#ff8800"}, expectedStatus: http.StatusCreated},
+		{name: "Rename To Existing Name", userID: 1, method: http.MethodPut, path: "/tags/1", body: models.TagRequest{Name: "home"}, expectedStatus: http.StatusConflict},
+		{name: "Keep Name", userID: 1, method: http.MethodPut, path: "/tags/1", body: models.TagRequest{Name: "work", Color: "#000"}, expectedStatus: http.StatusOK},
+		{name: "Update Tag Of Other User", userID: 1, method: http.MethodPut, path: "/tags/2", body: models.TagRequest{Name: "chores"}, expectedStatus: http.StatusNotFound},
+		{name: "Delete Tag Of Other User", userID: 1, method: http.MethodDelete, path: "/tags/2", expectedStatus: http.StatusNotFound},
+		{name: "Invalid Tag ID", userID: 1, method: http.MethodDelete, path: "/tags/abc", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(router, tt.userID, tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	w := serveAs(router, 1, http.MethodGet, "/tags", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var tags []models.TagResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tags))
+	if assert.Len(t, tags, 2) {
+		assert.Equal(t, "home", tags[0].Name)
+		assert.Equal(t, "#ff8800", tags[0].Color)
+		assert.Equal(t, "work", tags[1].Name)
+		assert.Equal(t, "#000", tags[1].Color)
+	}
+}
+
+func TestTodoTags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.Todo{ID: 1, Description: "Write report", Status: "pending", UserID: 1})
+	common.DB.Create(&entities.Todo{ID: 2, Description: "Clean kitchen", Status: "pending", UserID: 1})
+	common.DB.Create(&entities.Todo{ID: 3, Description: "Other Todo", Status: "pending", UserID: 2})
+	common.DB.Create(&entities.Tag{ID: 1, UserID: 1, Name: "work"})
+	common.DB.Create(&entities.Tag{ID: 2, UserID: 1, Name: "urgent"})
+	common.DB.Create(&entities.Tag{ID: 3, UserID: 2, Name: "home"})
+
+	router := setupTagRouter()
+
+	tests := []struct {
+		name           string
+		userID         uint64
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{name: "Attach", userID: 1, method: http.MethodPut, path: "/todo/1/tags/1", expectedStatus: http.StatusOK},
+		{name: "Attach Again", userID: 1, method: http.MethodPut, path: "/todo/1/tags/1", expectedStatus: http.StatusOK},
+		{name: "Attach Second Tag", userID: 1, method: http.MethodPut, path: "/todo/1/tags/2", expectedStatus: http.StatusOK},
+		{name: "Attach To Other Todo", userID: 1, method: http.MethodPut, path: "/todo/2/tags/2", expectedStatus: http.StatusOK},
+		{name: "Attach Tag Of Other User", userID: 1, method: http.MethodPut, path: "/todo/1/tags/3", expectedStatus: http.StatusNotFound},
+		{name: "Attach To Todo Of Other User", userID: 1, method: http.MethodPut, path: "/todo/3/tags/1", expectedStatus: http.StatusForbidden},
+		{name: "Attach To Missing Todo", userID: 1, method: http.MethodPut, path: "/todo/9/tags/1", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(router, tt.userID, tt.method, tt.path, nil)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	listIDs := func(path string) []uint64 {
+		w := serveAs(router, 1, http.MethodGet, path, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response models.TodoListResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		ids := []uint64{}
+		for _, item := range response.Items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []uint64{1, 2}, listIDs("/todo?tag_id=1&tag_id=2"))
+	assert.ElementsMatch(t, []uint64{1}, listIDs("/todo?tag_id=1&tag_id=2&tag_mode=all"))
+	assert.ElementsMatch(t, []uint64{1}, listIDs("/todo?tag_id=1&tag_id=1&tag_mode=all"))
+	assert.ElementsMatch(t, []uint64{}, listIDs("/todo?tag_id=3"))
+
+	w := serveAs(router, 1, http.MethodGet, "/todo?tag_mode=some", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Renames show up on the todos
+	w = serveAs(router, 1, http.MethodPut, "/tags/1", models.TagRequest{Name: "office"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveAs(router, 1, http.MethodGet, "/todo/1", nil)
+	var todo models.TodoResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todo))
+	assert.Equal(t, []models.TagResponse{{ID: 1, Name: "office"}, {ID: 2, Name: "urgent"}}, todo.Tags)
+
+	// Detaching and deleting remove the tags from the todos
+	w = serveAs(router, 1, http.MethodDelete, "/todo/1/tags/1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveAs(router, 1, http.MethodDelete, "/tags/2", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var links int64
+	common.DB.Model(&entities.TodoTag{}).Count(&links)
+	assert.Equal(t, int64(0), links)
+
+	w = serveAs(router, 1, http.MethodGet, "/todo/1", nil)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todo))
+	assert.Equal(t, []models.TagResponse{}, todo.Tags)
+}
@@ -1,8 +1,15 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -18,7 +25,9 @@ type TodoHandler interface {
 	CreateTodo(context *gin.Context)
 	ReadTodo(context *gin.Context)
 	UpdateTodo(context *gin.Context)
+	PatchTodo(context *gin.Context)
 	DeleteTodo(context *gin.Context)
+	ListTodos(context *gin.Context)
 }
 
 type todoHandler struct {
@@ -55,11 +64,44 @@ func (h *todoHandler) CreateTodo(context *gin.Context) {
 		return
 	}
 
+	if err := validateSchedule(todoRequest.DueAt, todoRequest.RemindAt); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if todoRequest.OrganizationID != nil &&
+		!organizationAllowed(context, *todoRequest.OrganizationID, userID.(uint64), models.RoleMember) {
+		return
+	}
+
+	if !projectAllowed(context, todoRequest.ProjectID, nil, userID.(uint64), todoRequest.OrganizationID) {
+		return
+	}
+
 	todo := entities.Todo{
-		Description: todoRequest.Description,
-		Status:      string(models.Pending),
-		UserID:      userID.(uint64),
+		Description:    todoRequest.Description,
+		UserID:         userID.(uint64),
+		OrganizationID: todoRequest.OrganizationID,
+		ProjectID:      todoRequest.ProjectID,
+		DueAt:          todoRequest.DueAt,
+		RemindAt:       todoRequest.RemindAt,
+	}
+
+	// New todos start in the first state of their workflow
+	workflow, err := common.TodoWorkflow(common.DB, todo)
+	if err != nil {
+		zap.L().Error("Failed to find workflow",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
+	todo.Status = workflow.Initial().Name
 
 	result := common.DB.Create(&todo)
 	if result.Error != nil {
@@ -71,13 +113,7 @@ func (h *todoHandler) CreateTodo(context *gin.Context) {
 		return
 	}
 
-	todoResponse := models.TodoResponse{
-		ID:          todo.ID,
-		Description: todo.Description,
-		Status:      todo.Status,
-		CreatedAt:   todo.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   todo.UpdatedAt.Format(time.RFC3339),
-	}
+	todoResponse := newTodoResponse(todo)
 
 	zap.L().Info("Todo created successfully",
 		zap.Uint64("todo ID", todo.ID),
@@ -113,20 +149,19 @@ func (h *todoHandler) ReadTodo(context *gin.Context) {
 		return
 	}
 
-	if todo.UserID != userID {
-		zap.L().Error("User does not have permission to access this todo",
-			zap.String("url path", context.Request.URL.Path),
-		)
-		context.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this todo"})
+	if !todoAllowed(context, todo, userID.(uint64), todoRead) {
 		return
 	}
 
-	todoResponse := models.TodoResponse{
-		ID:          todo.ID,
-		Description: todo.Description,
-		Status:      todo.Status,
-		CreatedAt:   todo.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   todo.UpdatedAt.Format(time.RFC3339),
+	todoResponse := newTodoResponse(todo)
+
+	if err := loadTodoDetails(userID.(uint64), &todoResponse); err != nil {
+		zap.L().Error("Failed to find todo details",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
 	zap.L().Info("Todo found successfully",
@@ -143,8 +178,7 @@ func (h *todoHandler) UpdateTodo(context *gin.Context) {
 
 	id := context.Param("id")
 	// Check if ID is valid
-	ID, err := strconv.ParseUint(id, 10, 64)
-	if err != nil {
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
 		zap.L().Error("Invalid ID",
 			zap.String("url path", context.Request.URL.Path),
 			zap.Error(err),
@@ -176,11 +210,7 @@ func (h *todoHandler) UpdateTodo(context *gin.Context) {
 		return
 	}
 
-	if todo.UserID != userID {
-		zap.L().Error("User does not have permission to access this todo",
-			zap.String("url path", context.Request.URL.Path),
-		)
-		context.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this todo"})
+	if !todoAllowed(context, todo, userID.(uint64), todoWrite) {
 		return
 	}
 
@@ -195,7 +225,7 @@ func (h *todoHandler) UpdateTodo(context *gin.Context) {
 		return
 	}
 
-	if err := h.validate.StructPartial(todoUpdateRequest); err != nil {
+	if err := h.validate.Struct(todoUpdateRequest); err != nil {
 		zap.L().Error("Validation error",
 			zap.String("url path", context.Request.URL.Path),
 			zap.Error(err),
@@ -204,30 +234,211 @@ func (h *todoHandler) UpdateTodo(context *gin.Context) {
 		return
 	}
 
-	todo = entities.Todo{
-		ID:          ID,
-		Description: todoUpdateRequest.Description,
-		Status:      string(todoUpdateRequest.Status),
-		UserID:      userID.(uint64),
+	h.saveTodo(context, userID.(uint64), todo, todoUpdateRequest)
+}
+
+// PatchTodo changes a todo with an RFC 7396 merge patch or an RFC 6902 JSON
+// patch of its TodoUpdateRequest, so fields left out keep their values. Only
+// the fields the patch changes are validated.
+func (h *todoHandler) PatchTodo(context *gin.Context) {
+	// Ignoring exists check as we are using authentication middleware, so it should always exist
+	userID, _ := context.Get("userID")
+
+	id := context.Param("id")
+	// Check if ID is valid
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		zap.L().Error("Invalid ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
 	}
 
-	// Update todo
-	result = common.DB.Save(&todo)
+	var todo entities.Todo
+
+	// Check if todo to patch exists, if not return 404
+	result := common.DB.First(&todo, id)
 	if result.Error != nil {
-		zap.L().Error("Failed to update todo",
+		if result.Error == gorm.ErrRecordNotFound {
+			zap.L().Error("Todo not found",
+				zap.String("url path", context.Request.URL.Path),
+				zap.Error(result.Error),
+			)
+			context.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+			return
+		}
+
+		zap.L().Error("Failed to find todo to patch",
 			zap.String("url path", context.Request.URL.Path),
 			zap.Error(result.Error),
 		)
+
 		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
 	}
 
-	todoResponse := models.TodoResponse{
-		ID:          todo.ID,
+	if !todoAllowed(context, todo, userID.(uint64), todoWrite) {
+		return
+	}
+
+	patch, err := io.ReadAll(context.Request.Body)
+	if err != nil {
+		zap.L().Error("Failed to read patch",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	document, err := json.Marshal(models.TodoUpdateRequest{
 		Description: todo.Description,
-		Status:      todo.Status,
-		CreatedAt:   todo.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   todo.UpdatedAt.Format(time.RFC3339),
+		Status:      models.Status(todo.Status),
+		DueAt:       todo.DueAt,
+		RemindAt:    todo.RemindAt,
+		ProjectID:   todo.ProjectID,
+	})
+	if err != nil {
+		zap.L().Error("Failed to encode todo",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var patched []byte
+
+	switch context.ContentType() {
+	case "application/json-patch+json":
+		patched, err = common.JSONPatch(document, patch)
+	case "application/merge-patch+json", "application/json":
+		patched, err = common.MergePatch(document, patch)
+	default:
+		zap.L().Error("Unsupported patch type",
+			zap.String("url path", context.Request.URL.Path),
+			zap.String("content type", context.ContentType()),
+		)
+		context.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported patch type"})
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to apply patch",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+
+		if errors.Is(err, common.ErrPatchTestFailed) {
+			context.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var todoUpdateRequest models.TodoUpdateRequest
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&todoUpdateRequest); err != nil {
+		zap.L().Error("Failed to decode patched todo",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields, err := changedFields(document, patched)
+	if err != nil {
+		zap.L().Error("Failed to compare patched todo",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(fields) > 0 {
+		if err := h.validate.StructPartial(todoUpdateRequest, fields...); err != nil {
+			zap.L().Error("Validation error",
+				zap.String("url path", context.Request.URL.Path),
+				zap.Error(err),
+			)
+			context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	h.saveTodo(context, userID.(uint64), todo, todoUpdateRequest)
+}
+
+// saveTodo replaces the todo with the update request and writes the updated
+// todo to the response.
+func (h *todoHandler) saveTodo(context *gin.Context, userID uint64, previous entities.Todo, todoUpdateRequest models.TodoUpdateRequest) {
+	if err := validateSchedule(todoUpdateRequest.DueAt, todoUpdateRequest.RemindAt); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !projectAllowed(context, todoUpdateRequest.ProjectID, previous.ProjectID, previous.UserID, previous.OrganizationID) {
+		return
+	}
+
+	// The creator, organization and creation time of a todo don't change
+	todo := entities.Todo{
+		ID:             previous.ID,
+		Description:    todoUpdateRequest.Description,
+		Status:         string(todoUpdateRequest.Status),
+		UserID:         previous.UserID,
+		OrganizationID: previous.OrganizationID,
+		ProjectID:      todoUpdateRequest.ProjectID,
+		DueAt:          todoUpdateRequest.DueAt,
+		RemindAt:       todoUpdateRequest.RemindAt,
+		CreatedAt:      previous.CreatedAt,
+	}
+
+	state, ok := changeStatus(context, previous, &todo)
+	if !ok {
+		return
+	}
+
+	// Update todo
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&todo).Error; err != nil {
+			return err
+		}
+
+		if state.Category != models.Completed || !todoUpdateRequest.CompleteItems {
+			return nil
+		}
+
+		return tx.Model(&entities.ChecklistItem{}).Where("todo_id = ?", todo.ID).Update("done", true).Error
+	})
+	if err != nil {
+		zap.L().Error("Failed to update todo",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	todoResponse := newTodoResponse(todo)
+
+	if err := loadTodoDetails(userID, &todoResponse); err != nil {
+		zap.L().Error("Failed to find todo details",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
 	zap.L().Info("Todo updated successfully",
@@ -277,11 +488,7 @@ func (h *todoHandler) DeleteTodo(context *gin.Context) {
 		return
 	}
 
-	if todo.UserID != userID {
-		zap.L().Error("User does not have permission to access this todo",
-			zap.String("url path", context.Request.URL.Path),
-		)
-		context.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this todo"})
+	if !todoAllowed(context, todo, userID.(uint64), todoDelete) {
 		return
 	}
 
@@ -302,3 +509,402 @@ func (h *todoHandler) DeleteTodo(context *gin.Context) {
 
 	context.JSON(http.StatusOK, gin.H{"message": "Todo deleted successfully"})
 }
+
+// todoSortColumns maps the sortable columns to the value stored in the cursor.
+// Due and completion times may be NULL, those todos are listed last.
+var todoSortColumns = map[string]func(todo entities.Todo) *time.Time{
+	"created_at":   func(todo entities.Todo) *time.Time { return &todo.CreatedAt },
+	"updated_at":   func(todo entities.Todo) *time.Time { return &todo.UpdatedAt },
+	"due_at":       func(todo entities.Todo) *time.Time { return todo.DueAt },
+	"completed_at": func(todo entities.Todo) *time.Time { return todo.CompletedAt },
+}
+
+func (h *todoHandler) ListTodos(context *gin.Context) {
+	// Ignoring exists check as we are using authentication middleware, so it should always exist
+	userID, _ := context.Get("userID")
+
+	var query models.TodoListQuery
+
+	if err := context.ShouldBindQuery(&query); err != nil {
+		zap.L().Error("Failed to bind query",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validate.Struct(query); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if query.Sort == "" {
+		query.Sort = "created_at"
+	}
+	if query.Order == "" {
+		query.Order = "desc"
+	}
+	if query.Limit == 0 {
+		query.Limit = 20
+	}
+
+	db := common.DB.Where("user_id = ? AND organization_id IS NULL", userID)
+	switch {
+	case query.Organization != nil:
+		if !organizationAllowed(context, *query.Organization, userID.(uint64), models.RoleViewer) {
+			return
+		}
+		db = common.DB.Where("organization_id = ?", *query.Organization)
+	case query.Shared:
+		shared := common.DB.Model(&entities.TodoShare{}).Select("todo_id").Where("user_id = ?", userID)
+		db = common.DB.Where("id IN (?) AND organization_id IS NULL", shared)
+	}
+
+	db = applyTodoFilters(db, query)
+	db = applyTagFilter(db, query, userID.(uint64))
+
+	if query.Cursor != "" {
+		cursor, err := common.DecodeCursor(query.Cursor)
+		if err != nil || cursor.Sort != query.Sort {
+			zap.L().Error("Invalid cursor",
+				zap.String("url path", context.Request.URL.Path),
+			)
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+
+		operator := "<"
+		if query.Order == "asc" {
+			operator = ">"
+		}
+		if cursor.Value == nil {
+			// Only todos without a value are left, ordered by ID
+			db = db.Where(fmt.Sprintf("%[1]s IS NULL AND id %[2]s ?", query.Sort, operator), cursor.ID)
+		} else {
+			db = db.Where(
+				fmt.Sprintf("(%[1]s %[2]s ?) OR (%[1]s = ? AND id %[2]s ?) OR %[1]s IS NULL", query.Sort, operator),
+				*cursor.Value, *cursor.Value, cursor.ID,
+			)
+		}
+	}
+
+	var todos []entities.Todo
+
+	// Fetch one extra row to find out whether there is a next page
+	result := db.
+		Order(fmt.Sprintf("%[1]s IS NULL, %[1]s %[2]s, id %[2]s", query.Sort, query.Order)).
+		Limit(query.Limit + 1).
+		Find(&todos)
+	if result.Error != nil {
+		zap.L().Error("Failed to list todos",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response := models.TodoListResponse{
+		Items: []models.TodoResponse{},
+	}
+
+	if len(todos) > query.Limit {
+		todos = todos[:query.Limit]
+		last := todos[len(todos)-1]
+		response.NextCursor = common.EncodeCursor(common.Cursor{
+			Sort:  query.Sort,
+			Value: todoSortColumns[query.Sort](last),
+			ID:    last.ID,
+		})
+	}
+
+	for _, todo := range todos {
+		response.Items = append(response.Items, newTodoResponse(todo))
+	}
+
+	items := make([]*models.TodoResponse, 0, len(response.Items))
+	for i := range response.Items {
+		items = append(items, &response.Items[i])
+	}
+	if err := loadTodoDetails(userID.(uint64), items...); err != nil {
+		zap.L().Error("Failed to find todo details",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Todos listed successfully",
+		zap.Int("count", len(response.Items)),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, response)
+}
+
+func applyTodoFilters(db *gorm.DB, query models.TodoListQuery) *gorm.DB {
+	if query.Project != nil {
+		if *query.Project == 0 {
+			db = db.Where("project_id IS NULL")
+		} else {
+			db = db.Where("project_id = ?", *query.Project)
+		}
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.CreatedAfter != nil {
+		db = db.Where("created_at >= ?", *query.CreatedAfter)
+	}
+	if query.CreatedBefore != nil {
+		db = db.Where("created_at < ?", *query.CreatedBefore)
+	}
+	if query.UpdatedAfter != nil {
+		db = db.Where("updated_at >= ?", *query.UpdatedAfter)
+	}
+	if query.UpdatedBefore != nil {
+		db = db.Where("updated_at < ?", *query.UpdatedBefore)
+	}
+	if query.DueAfter != nil {
+		db = db.Where("due_at >= ?", *query.DueAfter)
+	}
+	if query.DueBefore != nil {
+		db = db.Where("due_at < ?", *query.DueBefore)
+	}
+	if query.Overdue != nil {
+		overdue := "due_at IS NOT NULL AND due_at < ? AND completed_at IS NULL"
+		if *query.Overdue {
+			db = db.Where(overdue, time.Now())
+		} else {
+			db = db.Not(overdue, time.Now())
+		}
+	}
+
+	return db
+}
+
+type todoAction int
+
+const (
+	todoRead todoAction = iota
+	todoWrite
+	todoDelete
+)
+
+// todoAllowed checks what the user may do with a todo and answers with 403
+// otherwise. Private todos are only accessible to their creator. In an
+// organization viewers can read todos, members can also change them and
+// delete their own, and admins can delete any. Shares give access to a single
+// todo on top of that, but only its creator can delete it.
+func todoAllowed(context *gin.Context, todo entities.Todo, userID uint64, action todoAction) bool {
+	if todo.OrganizationID == nil && todo.UserID == userID {
+		return true
+	}
+
+	// Shares only apply to private todos, so they can't outlive a role
+	if todo.OrganizationID == nil && todo.UserID != userID && action != todoDelete {
+		permission, err := common.TodoSharePermission(common.DB, todo.ID, userID)
+		if err != nil {
+			zap.L().Error("Failed to find todo share",
+				zap.String("url path", context.Request.URL.Path),
+				zap.Error(err),
+			)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return false
+		}
+
+		if permission == models.ShareEdit || (permission == models.ShareRead && action == todoRead) {
+			return true
+		}
+	}
+
+	if todo.OrganizationID == nil {
+		zap.L().Error("User does not have permission to access this todo",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this todo"})
+		return false
+	}
+
+	required := models.RoleViewer
+	switch {
+	case action == todoWrite:
+		required = models.RoleMember
+	case action == todoDelete && todo.UserID == userID:
+		required = models.RoleMember
+	case action == todoDelete:
+		required = models.RoleAdmin
+	}
+
+	return organizationAllowed(context, *todo.OrganizationID, userID, required)
+}
+
+// organizationAllowed checks the role of the user in an organization and
+// answers with 403 when it isn't at least min.
+func organizationAllowed(context *gin.Context, organizationID, userID uint64, min models.Role) bool {
+	role, err := common.OrganizationRole(common.DB, organizationID, userID)
+	if err != nil {
+		zap.L().Error("Failed to find organization role",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if !role.AtLeast(min) {
+		zap.L().Error("User does not have permission to access this todo",
+			zap.String("url path", context.Request.URL.Path),
+			zap.String("role", string(role)),
+		)
+		context.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this todo"})
+		return false
+	}
+
+	return true
+}
+
+// changeStatus checks the status of an updated todo against its workflow and
+// answers with 400 for unknown statuses and illegal transitions. Todos moved
+// to a project with another workflow may take any of its states. The todo is
+// completed when entering a completed state.
+func changeStatus(context *gin.Context, previous entities.Todo, todo *entities.Todo) (models.WorkflowState, bool) {
+	workflow, err := common.TodoWorkflow(common.DB, *todo)
+	if err != nil {
+		zap.L().Error("Failed to find workflow",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return models.WorkflowState{}, false
+	}
+
+	state, ok := workflow.State(todo.Status)
+	if !ok {
+		zap.L().Error("Unknown status",
+			zap.String("url path", context.Request.URL.Path),
+			zap.String("status", todo.Status),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown status %q", todo.Status)})
+		return state, false
+	}
+
+	if _, known := workflow.State(previous.Status); known && !workflow.Allows(previous.Status, todo.Status) {
+		zap.L().Error("Illegal status transition",
+			zap.String("url path", context.Request.URL.Path),
+			zap.String("from", previous.Status),
+			zap.String("to", todo.Status),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Status can not change from %q to %q", previous.Status, todo.Status),
+		})
+		return state, false
+	}
+
+	if state.Category == models.Completed {
+		todo.CompletedAt = previous.CompletedAt
+		if todo.CompletedAt == nil {
+			now := time.Now()
+			todo.CompletedAt = &now
+		}
+	}
+
+	return state, true
+}
+
+// validateSchedule checks that a reminder, when both are set, doesn't fire after the due date.
+func validateSchedule(dueAt, remindAt *time.Time) error {
+	if dueAt != nil && remindAt != nil && remindAt.After(*dueAt) {
+		return errors.New("remind_at must not be after due_at")
+	}
+
+	return nil
+}
+
+// todoUpdateFields maps the JSON names of TodoUpdateRequest to its fields.
+var todoUpdateFields = jsonFields(reflect.TypeOf(models.TodoUpdateRequest{}))
+
+func jsonFields(t reflect.Type) map[string]string {
+	fields := make(map[string]string)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = t.Field(i).Name
+		}
+	}
+
+	return fields
+}
+
+// changedFields returns the TodoUpdateRequest fields a patch changed.
+func changedFields(document, patched []byte) ([]string, error) {
+	var before, after map[string]json.RawMessage
+
+	if err := json.Unmarshal(document, &before); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	for name, field := range todoUpdateFields {
+		value, ok := after[name]
+		if previous, existed := before[name]; ok != existed || !bytes.Equal(value, previous) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+// isOverdue reports whether a todo passed its due date without being completed.
+func isOverdue(todo entities.Todo) bool {
+	return todo.DueAt != nil &&
+		todo.DueAt.Before(time.Now()) &&
+		todo.CompletedAt == nil
+}
+
+// loadTodoDetails fills in what is stored apart from the todos: the tags of
+// the user and the checklist progress.
+func loadTodoDetails(userID uint64, responses ...*models.TodoResponse) error {
+	if err := loadTodoTags(userID, responses...); err != nil {
+		return err
+	}
+
+	return loadChecklistProgress(responses...)
+}
+
+func newTodoResponse(todo entities.Todo) models.TodoResponse {
+	response := models.TodoResponse{
+		ID:             todo.ID,
+		Description:    todo.Description,
+		Status:         todo.Status,
+		UserID:         todo.UserID,
+		OrganizationID: todo.OrganizationID,
+		ProjectID:      todo.ProjectID,
+		Overdue:        isOverdue(todo),
+		Tags:           []models.TagResponse{},
+		CreatedAt:      todo.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      todo.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if todo.DueAt != nil {
+		response.DueAt = todo.DueAt.Format(time.RFC3339)
+	}
+	if todo.RemindAt != nil {
+		response.RemindAt = todo.RemindAt.Format(time.RFC3339)
+	}
+	if todo.CompletedAt != nil {
+		response.CompletedAt = todo.CompletedAt.Format(time.RFC3339)
+	}
+
+	return response
+}
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -34,6 +37,9 @@ func TestCreateTodo(t *testing.T) {
 	testDB := common.SetupTestDB()
 	common.SetDB(testDB) // Set the mock database for testing
 
+	dueAt := time.Now().Add(24 * time.Hour)
+	remindAt := dueAt.Add(-time.Hour)
+
 	tests := []struct {
 		name           string
 		requestBody    interface{}
@@ -58,6 +64,26 @@ func TestCreateTodo(t *testing.T) {
 			mockBehavior:   func(mockTodoHandler *mocks.TodoHandler) {},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "Successfull Todo Creation With Due Date",
+			requestBody: models.TodoRequest{
+				Description: "Test Todo",
+				DueAt:       &dueAt,
+				RemindAt:    &remindAt,
+			},
+			mockBehavior:   func(mockTodoHandler *mocks.TodoHandler) {},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Reminder After Due Date",
+			requestBody: models.TodoRequest{
+				Description: "Test Todo",
+				DueAt:       &remindAt,
+				RemindAt:    &dueAt,
+			},
+			mockBehavior:   func(mockTodoHandler *mocks.TodoHandler) {},
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -286,3 +312,334 @@ func TestDeleteTodo(t *testing.T) {
 		})
 	}
 }
+
+func TestListTodos(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uint64(1)
+
+	todoHandler := handlers.NewTodoHandler()
+
+	router := gin.Default()
+	router.GET("/", func(c *gin.Context) {
+		c.Set("userID", userID) // Set userID in context
+		todoHandler.ListTodos(c)
+	})
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	createdAt := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	for i := uint64(1); i <= 5; i++ {
+		status := "pending"
+		var completedAt *time.Time
+		if i%2 == 0 {
+			status = "completed"
+			completedAt = &createdAt
+		}
+		common.DB.Create(&entities.Todo{
+			ID:          i,
+			Description: fmt.Sprintf("Test Todo %d", i),
+			Status:      status,
+			UserID:      userID,
+			CompletedAt: completedAt,
+			CreatedAt:   createdAt.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	common.DB.Create(&entities.Todo{ID: 6, Description: "Other Todo", Status: "pending", UserID: 2})
+
+	// Todo 1 is overdue, todo 2 passed its due date but is completed and todo 3 is due in the future
+	yesterday := time.Now().Add(-24 * time.Hour)
+	tomorrow := time.Now().Add(24 * time.Hour)
+	common.DB.Model(&entities.Todo{ID: 1}).Update("due_at", yesterday)
+	common.DB.Model(&entities.Todo{ID: 2}).Update("due_at", yesterday)
+	common.DB.Model(&entities.Todo{ID: 3}).Update("due_at", tomorrow)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []uint64
+		expectedNext   bool
+	}{
+		{
+			name:           "List All Todos",
+			query:          "",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []uint64{5, 4, 3, 2, 1},
+		},
+		{
+			name:           "Filter By Status",
+			query:          "?status=completed",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []uint64{4, 2},
+		},
+		{
+			name:           "Filter By Created Range",
+			query:          "?created_after=2024-07-01T14:00:00Z&created_before=2024-07-01T16:00:00Z",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []uint64{3, 2},
+		},
+		{
+			name:           "Filter Overdue",
+			query:          "?overdue=true",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []uint64{1},
+		},
+		{
+			name:           "Filter Not Overdue",
+			query:          "?overdue=false",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []uint64{5, 4, 3, 2},
+		},
+		{
+			name:           "Filter Due Before",
+			query:          "?due_before=" + url.QueryEscape(time.Now().Format(time.RFC3339)),
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []uint64{2, 1},
+		},
+		{
+			name:           "Sort Ascending With Limit",
+			query:          "?sort=created_at&order=asc&limit=2",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []uint64{1, 2},
+			expectedNext:   true,
+		},
+		{
+			name:           "Invalid Sort Column",
+			query:          "?sort=description",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Cursor",
+			query:          "?cursor=invalid",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response models.TodoListResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+			var ids []uint64
+			for _, item := range response.Items {
+				ids = append(ids, item.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.Equal(t, tt.expectedNext, response.NextCursor != "")
+		})
+	}
+
+	t.Run("Follow Cursor", func(t *testing.T) {
+		var ids []uint64
+		query := "?limit=2"
+
+		for {
+			req, err := http.NewRequest(http.MethodGet, "/"+query, nil)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var response models.TodoListResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+			for _, item := range response.Items {
+				ids = append(ids, item.ID)
+			}
+			if response.NextCursor == "" {
+				break
+			}
+			query = "?limit=2&cursor=" + response.NextCursor
+		}
+
+		assert.Equal(t, []uint64{5, 4, 3, 2, 1}, ids)
+	})
+
+	// Todos 4 and 5 have no due date and todos 1 and 2 share one, so pages
+	// end on both NULL and tied values
+	cursorTests := []struct {
+		name        string
+		query       string
+		expectedIDs []uint64
+	}{
+		{
+			name:        "Follow Cursor By Due Date Ascending",
+			query:       "?sort=due_at&order=asc&limit=2",
+			expectedIDs: []uint64{1, 2, 3, 4, 5},
+		},
+		{
+			name:        "Follow Cursor By Due Date Descending",
+			query:       "?sort=due_at&order=desc&limit=2",
+			expectedIDs: []uint64{3, 2, 1, 5, 4},
+		},
+		{
+			name:        "Follow Cursor By Completion Ascending",
+			query:       "?sort=completed_at&order=asc&limit=1",
+			expectedIDs: []uint64{2, 4, 1, 3, 5},
+		},
+		{
+			name:        "Follow Cursor By Completion Descending",
+			query:       "?sort=completed_at&order=desc&limit=3",
+			expectedIDs: []uint64{4, 2, 5, 3, 1},
+		},
+	}
+
+	for _, tt := range cursorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []uint64
+			query := tt.query
+
+			for pages := 0; pages < 10; pages++ {
+				req, err := http.NewRequest(http.MethodGet, "/"+query, nil)
+				assert.NoError(t, err)
+
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if !assert.Equal(t, http.StatusOK, w.Code) {
+					return
+				}
+
+				var response models.TodoListResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+				for _, item := range response.Items {
+					ids = append(ids, item.ID)
+				}
+				if response.NextCursor == "" {
+					break
+				}
+				query = tt.query + "&cursor=" + response.NextCursor
+			}
+
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+
+	t.Run("Cursor Of Another Sort", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/?sort=due_at&order=asc&limit=2", nil)
+		assert.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response models.TodoListResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		req, err = http.NewRequest(http.MethodGet, "/?sort=completed_at&order=asc&limit=2&cursor="+response.NextCursor, nil)
+		assert.NoError(t, err)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestOrganizationTodoPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	// User 1 is the owner, 2 a member, 3 a viewer and 4 not in the organization
+	organizationID := uint64(1)
+	common.DB.Create(&entities.Organization{ID: organizationID, Name: "Team"})
+	common.DB.Create(&entities.OrganizationMember{OrganizationID: organizationID, UserID: 1, Role: string(models.RoleOwner)})
+	common.DB.Create(&entities.OrganizationMember{OrganizationID: organizationID, UserID: 2, Role: string(models.RoleMember)})
+	common.DB.Create(&entities.OrganizationMember{OrganizationID: organizationID, UserID: 3, Role: string(models.RoleViewer)})
+
+	common.DB.Create(&entities.Todo{ID: 1, Description: "Owner Todo", Status: "pending", UserID: 1, OrganizationID: &organizationID})
+	common.DB.Create(&entities.Todo{ID: 2, Description: "Member Todo", Status: "pending", UserID: 2, OrganizationID: &organizationID})
+	common.DB.Create(&entities.Todo{ID: 3, Description: "Private Todo", Status: "pending", UserID: 1})
+
+	todoHandler := handlers.NewTodoHandler()
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseUint(c.GetHeader("X-User"), 10, 64)
+		c.Set("userID", userID)
+		c.Next()
+	})
+	router.POST("/", todoHandler.CreateTodo)
+	router.GET("/", todoHandler.ListTodos)
+	router.GET("/:id", todoHandler.ReadTodo)
+	router.PUT("/:id", todoHandler.UpdateTodo)
+	router.DELETE("/:id", todoHandler.DeleteTodo)
+
+	update := `{"description":"Updated Todo","status":"in_progress"}`
+	create := fmt.Sprintf(`{"description":"Shared Todo","organization_id":%d}`, organizationID)
+
+	tests := []struct {
+		name           string
+		userID         int
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "Viewer Reads", userID: 3, method: http.MethodGet, path: "/1", expectedStatus: http.StatusOK},
+		{name: "Outsider Reads", userID: 4, method: http.MethodGet, path: "/1", expectedStatus: http.StatusForbidden},
+		{name: "Member Reads Private Todo", userID: 2, method: http.MethodGet, path: "/3", expectedStatus: http.StatusForbidden},
+		{name: "Viewer Lists", userID: 3, method: http.MethodGet, path: "/?organization_id=1", expectedStatus: http.StatusOK},
+		{name: "Outsider Lists", userID: 4, method: http.MethodGet, path: "/?organization_id=1", expectedStatus: http.StatusForbidden},
+		{name: "Viewer Creates", userID: 3, method: http.MethodPost, path: "/", body: create, expectedStatus: http.StatusForbidden},
+		{name: "Member Creates", userID: 2, method: http.MethodPost, path: "/", body: create, expectedStatus: http.StatusOK},
+		{name: "Viewer Updates", userID: 3, method: http.MethodPut, path: "/1", body: update, expectedStatus: http.StatusForbidden},
+		{name: "Member Updates", userID: 2, method: http.MethodPut, path: "/1", body: update, expectedStatus: http.StatusOK},
+		{name: "Member Deletes Todo Of Others", userID: 2, method: http.MethodDelete, path: "/1", expectedStatus: http.StatusForbidden},
+		{name: "Member Deletes Own Todo", userID: 2, method: http.MethodDelete, path: "/2", expectedStatus: http.StatusOK},
+		{name: "Owner Deletes Todo Of Others", userID: 1, method: http.MethodDelete, path: "/4", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-User", strconv.Itoa(tt.userID))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	// Updates keep the creator and the organization
+	var todo entities.Todo
+	common.DB.First(&todo, 1)
+	assert.Equal(t, uint64(1), todo.UserID)
+	if assert.NotNil(t, todo.OrganizationID) {
+		assert.Equal(t, organizationID, *todo.OrganizationID)
+	}
+
+	// Organization todos aren't in the private list of their creator
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-User", "1")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response models.TodoListResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Items, 1) {
+		assert.Equal(t, uint64(3), response.Items[0].ID)
+	}
+}
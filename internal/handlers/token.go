@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+)
+
+type TokenHandler interface {
+	CreateToken(context *gin.Context)
+	ListTokens(context *gin.Context)
+	RevokeToken(context *gin.Context)
+}
+
+type tokenHandler struct {
+	validate *validator.Validate
+}
+
+func NewTokenHandler() TokenHandler {
+	return &tokenHandler{
+		validate: validator.New(),
+	}
+}
+
+func (h *tokenHandler) CreateToken(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var request models.CreateTokenRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		zap.L().Error("Token expiry is in the past",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	token, prefix, err := common.GeneratePersonalAccessToken()
+	if err != nil {
+		zap.L().Error("Failed to generate token",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	pat := entities.PersonalAccessToken{
+		UserID:      userID.(uint64),
+		Name:        request.Name,
+		TokenHash:   common.HashToken(token),
+		TokenPrefix: prefix,
+		Scopes:      strings.Join(request.Scopes, " "),
+		ExpiresAt:   request.ExpiresAt,
+	}
+
+	result := common.DB.Create(&pat)
+	if result.Error != nil {
+		zap.L().Error("Failed to create token",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	zap.L().Info("Personal access token created",
+		zap.Uint64("user ID", pat.UserID),
+		zap.Uint64("token ID", pat.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	// The token is only shown once
+	context.JSON(http.StatusCreated, models.CreateTokenResponse{
+		TokenResponse: newTokenResponse(pat),
+		Token:         token,
+	})
+}
+
+func (h *tokenHandler) ListTokens(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var pats []entities.PersonalAccessToken
+	result := common.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&pats)
+	if result.Error != nil {
+		zap.L().Error("Failed to list tokens",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response := make([]models.TokenResponse, 0, len(pats))
+	for _, pat := range pats {
+		response = append(response, newTokenResponse(pat))
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+func (h *tokenHandler) RevokeToken(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid token ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	result := common.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&entities.PersonalAccessToken{})
+	if result.Error != nil {
+		zap.L().Error("Failed to revoke token",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		zap.L().Error("Token not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+
+	zap.L().Info("Personal access token revoked",
+		zap.Uint64("user ID", userID.(uint64)),
+		zap.Uint64("token ID", id),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Token revoked successfully"})
+}
+
+func newTokenResponse(pat entities.PersonalAccessToken) models.TokenResponse {
+	response := models.TokenResponse{
+		ID:        pat.ID,
+		Name:      pat.Name,
+		Prefix:    pat.TokenPrefix,
+		Scopes:    common.TokenScopes(&pat),
+		CreatedAt: pat.CreatedAt.Format(time.RFC3339),
+	}
+
+	if pat.ExpiresAt != nil {
+		response.ExpiresAt = pat.ExpiresAt.Format(time.RFC3339)
+	}
+
+	if pat.LastUsedAt != nil {
+		response.LastUsedAt = pat.LastUsedAt.Format(time.RFC3339)
+	}
+
+	return response
+}
@@ -0,0 +1,216 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/internal/handlers"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+func TestCreateToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	tokenHandler := handlers.NewTokenHandler()
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint64(1))
+		c.Next()
+	})
+	router.POST("/tokens", tokenHandler.CreateToken)
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+
+	tests := []struct {
+		name           string
+		requestBody    models.CreateTokenRequest
+		expectedStatus int
+	}{
+		{
+			name:           "Successful Creation",
+			requestBody:    models.CreateTokenRequest{Name: "CI", Scopes: []string{models.ScopeTodoRead}, ExpiresAt: &future},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Unknown Scope",
+			requestBody:    models.CreateTokenRequest{Name: "CI", Scopes: []string{"admin"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "No Scopes",
+			requestBody:    models.CreateTokenRequest{Name: "CI"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Expiry In The Past",
+			requestBody:    models.CreateTokenRequest{Name: "CI", Scopes: []string{models.ScopeTodoRead}, ExpiresAt: &past},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody, err := json.Marshal(tt.requestBody)
+			assert.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/tokens", bytes.NewBuffer(reqBody))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusCreated {
+				var response models.CreateTokenResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.True(t, common.IsPersonalAccessToken(response.Token))
+				assert.Equal(t, response.Token[:len(response.Prefix)], response.Prefix)
+
+				// Only the hash of the token is stored
+				var pat entities.PersonalAccessToken
+				common.DB.First(&pat, response.ID)
+				assert.Equal(t, common.HashToken(response.Token), pat.TokenHash)
+				assert.Equal(t, models.ScopeTodoRead, pat.Scopes)
+			}
+		})
+	}
+}
+
+func TestListAndRevokeTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.PersonalAccessToken{ID: 1, UserID: 1, Name: "CI", TokenHash: "hash1", TokenPrefix: "tdp_abcdefgh", Scopes: "todo:read todo:write"})
+	common.DB.Create(&entities.PersonalAccessToken{ID: 2, UserID: 2, Name: "Other", TokenHash: "hash2", TokenPrefix: "tdp_ijklmnop", Scopes: "todo:read"})
+
+	tokenHandler := handlers.NewTokenHandler()
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint64(1))
+		c.Next()
+	})
+	router.GET("/tokens", tokenHandler.ListTokens)
+	router.DELETE("/tokens/:id", tokenHandler.RevokeToken)
+
+	req, err := http.NewRequest(http.MethodGet, "/tokens", nil)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response []models.TokenResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response, 1) {
+		assert.Equal(t, "CI", response[0].Name)
+		assert.Equal(t, []string{models.ScopeTodoRead, models.ScopeTodoWrite}, response[0].Scopes)
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{name: "Token Of Another User", id: "2", expectedStatus: http.StatusNotFound},
+		{name: "Invalid ID", id: "abc", expectedStatus: http.StatusBadRequest},
+		{name: "Successful Revocation", id: "1", expectedStatus: http.StatusOK},
+		{name: "Already Revoked", id: "1", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodDelete, "/tokens/"+tt.id, nil)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestAuthorizePersonalAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	token, prefix, err := common.GeneratePersonalAccessToken()
+	assert.NoError(t, err)
+	expired, _, err := common.GeneratePersonalAccessToken()
+	assert.NoError(t, err)
+
+	past := time.Now().Add(-time.Hour)
+	common.DB.Create(&entities.PersonalAccessToken{UserID: 1, Name: "CI", TokenHash: common.HashToken(token), TokenPrefix: prefix, Scopes: "todo:read"})
+	common.DB.Create(&entities.PersonalAccessToken{UserID: 1, Name: "Old", TokenHash: common.HashToken(expired), TokenPrefix: expired[:12], Scopes: "todo:read", ExpiresAt: &past})
+
+	authHandler := handlers.NewAuthHandler()
+
+	router := gin.Default()
+	router.POST("/authorize", authHandler.Authorize)
+
+	tests := []struct {
+		name           string
+		token          string
+		scope          string
+		expectedStatus int
+	}{
+		{name: "Valid Token", token: token, expectedStatus: http.StatusOK},
+		{name: "Valid Token With Scope", token: token, scope: models.ScopeTodoRead, expectedStatus: http.StatusOK},
+		{name: "Missing Scope", token: token, scope: models.ScopeTodoWrite, expectedStatus: http.StatusForbidden},
+		{name: "Expired Token", token: expired, expectedStatus: http.StatusUnauthorized},
+		{name: "Unknown Token", token: "tdp_unknown", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/authorize"
+			if tt.scope != "" {
+				path += "?scope=" + tt.scope
+			}
+
+			req, err := http.NewRequest(http.MethodPost, path, nil)
+			assert.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.AuthorizeResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, uint64(1), response.UserId)
+				assert.Equal(t, []string{models.ScopeTodoRead}, response.Scopes)
+			}
+		})
+	}
+
+	var pat entities.PersonalAccessToken
+	common.DB.First(&pat, "token_hash = ?", common.HashToken(token))
+	assert.NotNil(t, pat.LastUsedAt)
+}
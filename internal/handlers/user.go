@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,12 +11,14 @@ import (
 	"github.com/whitehead421/todo-backend/pkg/entities"
 	"github.com/whitehead421/todo-backend/pkg/models"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type UserHandler interface {
 	GetUser(context *gin.Context)
 	DeleteUser(context *gin.Context)
 	ChangePassword(context *gin.Context)
+	ChangeEmail(context *gin.Context)
 }
 
 type userHandler struct {
@@ -47,6 +50,7 @@ func (h *userHandler) GetUser(context *gin.Context) {
 		ID:        user.ID,
 		Email:     user.Email,
 		Name:      user.Name,
+		Locale:    user.Locale,
 		CreatedAt: user.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: user.CreatedAt.Format(time.RFC3339),
 	}
@@ -74,13 +78,42 @@ func (h *userHandler) DeleteUser(context *gin.Context) {
 		return
 	}
 
-	result = common.DB.Delete(&user)
-	if result.Error != nil {
+	// Organizations must not be left without an owner
+	organizationIDs, err := common.SoleOwnedOrganizations(common.DB, user.ID)
+	if err != nil {
+		zap.L().Error("Failed to find owned organizations",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(organizationIDs) > 0 {
+		zap.L().Error("User is the only owner of organizations",
+			zap.Uint64("user ID", user.ID),
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusConflict, gin.H{
+			"error":            "Transfer the ownership of your organizations before deleting your account",
+			"organization_ids": organizationIDs,
+		})
+		return
+	}
+
+	err = common.DB.Transaction(func(tx *gorm.DB) error {
+		if err := common.RevokeAllSessions(context, tx, user.ID); err != nil {
+			return err
+		}
+
+		return tx.Delete(&user).Error
+	})
+	if err != nil {
 		zap.L().Error("Failed to delete user",
 			zap.String("url path", context.Request.URL.Path),
-			zap.Error(result.Error),
+			zap.Error(err),
 		)
-		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -137,13 +170,20 @@ func (h *userHandler) ChangePassword(context *gin.Context) {
 
 	user.Password = common.HashPassword(request.NewPassword)
 
-	result = common.DB.Save(&user)
-	if result.Error != nil {
+	// Log out everywhere, the old password may have been compromised
+	err = common.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+
+		return common.RevokeAllSessions(context, tx, user.ID)
+	})
+	if err != nil {
 		zap.L().Error("Failed to save user",
 			zap.String("url path", context.Request.URL.Path),
-			zap.Error(result.Error),
+			zap.Error(err),
 		)
-		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -154,3 +194,141 @@ func (h *userHandler) ChangePassword(context *gin.Context) {
 
 	context.JSON(http.StatusOK, gin.H{"message": "You successfully changed your password."})
 }
+
+// ChangeEmail records the new address and emails it a confirmation link. The
+// address is only changed by ConfirmEmailChange.
+func (h *userHandler) ChangeEmail(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	var user entities.User
+
+	result := common.DB.First(&user, userID)
+	if result.Error != nil {
+		zap.L().Error("Failed to find user to change email",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	var request models.ChangeEmailRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !common.CheckPasswordHash(request.Password, user.Password) {
+		zap.L().Error("Invalid password",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+		return
+	}
+
+	if request.Email == user.Email {
+		zap.L().Error("Email is unchanged",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "This is already your email address"})
+		return
+	}
+
+	registered, err := emailRegistered(common.DB, request.Email, user.ID)
+	if err != nil {
+		zap.L().Error("Failed to find user",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if registered {
+		zap.L().Error("This email is already registered",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusConflict, gin.H{"error": "This email is already registered"})
+		return
+	}
+
+	token, err := common.GenerateSecureToken()
+	if err != nil {
+		zap.L().Error("Failed to generate email change token",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	change := entities.EmailChangeToken{
+		UserID:    user.ID,
+		NewEmail:  request.Email,
+		TokenHash: common.HashToken(token),
+		ExpiresAt: time.Now().Add(emailChangeTTL),
+	}
+
+	err = common.DB.Transaction(func(tx *gorm.DB) error {
+		// Only the most recent change stays pending
+		err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).
+			Delete(&entities.EmailChangeToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+
+		key := strconv.FormatUint(user.ID, 10)
+
+		err = common.EnqueueEvent(tx, models.EmailChangeRequested, key, models.EmailChangeRequestedPayload{
+			UserID:   user.ID,
+			Email:    user.Email,
+			Name:     user.Name,
+			Locale:   user.Locale,
+			NewEmail: change.NewEmail,
+		})
+		if err != nil {
+			return err
+		}
+
+		return common.EnqueueEvent(tx, models.EmailChangeConfirming, key, models.EmailChangeConfirmingPayload{
+			UserID:    user.ID,
+			Email:     change.NewEmail,
+			Name:      user.Name,
+			Locale:    user.Locale,
+			Token:     token,
+			ExpiresAt: change.ExpiresAt,
+		})
+	})
+	if err != nil {
+		zap.L().Error("Failed to request email change",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Email change requested",
+		zap.Uint64("user ID", user.ID),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "A confirmation link has been sent to the new email address"})
+}
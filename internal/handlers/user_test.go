@@ -6,8 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redismock/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/whitehead421/todo-backend/internal/handlers"
 	"github.com/whitehead421/todo-backend/mocks"
@@ -95,6 +97,10 @@ func TestDeleteUser(t *testing.T) {
 	testDB := common.SetupTestDB()
 	common.SetDB(testDB) // Set the mock database for testing
 
+	// Mock Redis setup
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+
 	tests := []struct {
 		name           string
 		userID         interface{}
@@ -110,6 +116,8 @@ func TestDeleteUser(t *testing.T) {
 					Name:  "Test User",
 					Email: "test@test.com",
 				})
+				mockRedis.ExpectSMembers("user_sessions:1").SetVal([]string{"token"})
+				mockRedis.ExpectDel("token", "user_sessions:1").SetVal(2)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -119,6 +127,30 @@ func TestDeleteUser(t *testing.T) {
 			mockBehavior:   func(mockUserHandler *mocks.UserHandler) {},
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name:   "Only Owner Of An Organization",
+			userID: uint64(2),
+			mockBehavior: func(mockUserHandler *mocks.UserHandler) {
+				common.DB.Create(&entities.User{ID: 2, Name: "Owner", Email: "owner@test.com"})
+				common.DB.Create(&entities.User{ID: 3, Name: "Admin", Email: "admin@test.com"})
+				common.DB.Create(&entities.Organization{ID: 1, Name: "Acme"})
+				common.DB.Create(&entities.OrganizationMember{OrganizationID: 1, UserID: 2, Role: string(models.RoleOwner)})
+				common.DB.Create(&entities.OrganizationMember{OrganizationID: 1, UserID: 3, Role: string(models.RoleAdmin)})
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "One Of Several Owners",
+			userID: uint64(3),
+			mockBehavior: func(mockUserHandler *mocks.UserHandler) {
+				common.DB.Create(&entities.Organization{ID: 2, Name: "Globex"})
+				common.DB.Create(&entities.OrganizationMember{OrganizationID: 2, UserID: 2, Role: string(models.RoleOwner)})
+				common.DB.Create(&entities.OrganizationMember{OrganizationID: 2, UserID: 3, Role: string(models.RoleOwner)})
+				mockRedis.ExpectSMembers("user_sessions:3").SetVal([]string{})
+				mockRedis.ExpectDel("user_sessions:3").SetVal(0)
+			},
+			expectedStatus: http.StatusOK,
+		},
 	}
 
 	for _, tt := range tests {
@@ -159,6 +191,10 @@ func TestDeleteUser(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			mockUserHandler.AssertExpectations(t)
 
+			if err := mockRedis.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+
 			router = nil
 		})
 	}
@@ -169,6 +205,10 @@ func TestChangePassword(t *testing.T) {
 	testDB := common.SetupTestDB()
 	common.SetDB(testDB) // Set the mock database for testing
 
+	// Mock Redis setup
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+
 	tests := []struct {
 		name           string
 		userID         interface{}
@@ -192,6 +232,10 @@ func TestChangePassword(t *testing.T) {
 					Name:     "Test User",
 					Password: hashedOldPassword,
 				})
+				common.DB.Create(&entities.Session{ID: "session", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)})
+				mockRedis.ExpectSet("revoked_session:session", "revoked", 15*time.Minute).SetVal("OK")
+				mockRedis.ExpectSMembers("user_sessions:1").SetVal([]string{"token"})
+				mockRedis.ExpectDel("token", "user_sessions:1").SetVal(2)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -272,6 +316,117 @@ func TestChangePassword(t *testing.T) {
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var session entities.Session
+				common.DB.First(&session, "id = ?", "session")
+				assert.NotNil(t, session.RevokedAt)
+			}
+
+			if err := mockRedis.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
 		})
 	}
 }
+
+func TestChangeEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.User{
+		ID:       1,
+		Email:    "old@example.com",
+		Name:     "Test User",
+		Password: common.HashPassword("password"),
+		Verified: true,
+	})
+	common.DB.Create(&entities.User{
+		ID:    2,
+		Email: "taken@example.com",
+		Name:  "Other User",
+	})
+
+	userHandler := handlers.NewUserHandler()
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint64(1))
+		c.Next()
+	})
+	router.PUT("/email", userHandler.ChangeEmail)
+
+	tests := []struct {
+		name           string
+		requestBody    models.ChangeEmailRequest
+		expectedStatus int
+	}{
+		{
+			name:           "Invalid email",
+			requestBody:    models.ChangeEmailRequest{Email: "invalid-email", Password: "password"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Wrong password",
+			requestBody:    models.ChangeEmailRequest{Email: "new@example.com", Password: "wrong-password"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Same email",
+			requestBody:    models.ChangeEmailRequest{Email: "old@example.com", Password: "password"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Email is already registered",
+			requestBody:    models.ChangeEmailRequest{Email: "taken@example.com", Password: "password"},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "First request",
+			requestBody:    models.ChangeEmailRequest{Email: "first@example.com", Password: "password"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Successful request",
+			requestBody:    models.ChangeEmailRequest{Email: "new@example.com", Password: "password"},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody, err := json.Marshal(tt.requestBody)
+			assert.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPut, "/email", bytes.NewBuffer(reqBody))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	// The address only changes on confirmation
+	var user entities.User
+	common.DB.First(&user, 1)
+	assert.Equal(t, "old@example.com", user.Email)
+
+	// Only the latest change stays pending
+	var changes []entities.EmailChangeToken
+	common.DB.Find(&changes)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, "new@example.com", changes[0].NewEmail)
+	}
+
+	var notices, confirmations int64
+	common.DB.Model(&entities.OutboxEvent{}).Where("event_type = ?", models.EmailChangeRequested).Count(&notices)
+	common.DB.Model(&entities.OutboxEvent{}).Where("event_type = ?", models.EmailChangeConfirming).Count(&confirmations)
+	assert.Equal(t, int64(2), notices)
+	assert.Equal(t, int64(2), confirmations)
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// WorkflowHandler manages the default workflow of the user on /workflow and
+// the workflow of a project on /projects/:id/workflow.
+type WorkflowHandler interface {
+	GetWorkflow(context *gin.Context)
+	SetWorkflow(context *gin.Context)
+	DeleteWorkflow(context *gin.Context)
+}
+
+type workflowHandler struct {
+	validate *validator.Validate
+}
+
+func NewWorkflowHandler() WorkflowHandler {
+	return &workflowHandler{
+		validate: validator.New(),
+	}
+}
+
+// GetWorkflow returns the workflow todos follow, which falls back to the
+// default of the user and then to the built-in one.
+func (h *workflowHandler) GetWorkflow(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	projectID, ok := h.findProject(context, userID.(uint64))
+	if !ok {
+		return
+	}
+
+	workflow, custom, err := common.EffectiveWorkflow(common.DB, userID.(uint64), projectID)
+	if err != nil {
+		zap.L().Error("Failed to find workflow",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, models.WorkflowResponse{Workflow: workflow, Custom: custom})
+}
+
+func (h *workflowHandler) SetWorkflow(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	projectID, ok := h.findProject(context, userID.(uint64))
+	if !ok {
+		return
+	}
+
+	var request models.Workflow
+
+	if err := context.ShouldBindJSON(&request); err != nil {
+		zap.L().Error("Failed to bind JSON",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validate.Struct(request); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := request.Check(); err != nil {
+		zap.L().Error("Validation error",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := common.SaveWorkflow(common.DB, userID.(uint64), projectID, request); err != nil {
+		zap.L().Error("Failed to save workflow",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Workflow saved successfully",
+		zap.Uint64("user ID", userID.(uint64)),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, models.WorkflowResponse{Workflow: request, Custom: true})
+}
+
+// DeleteWorkflow goes back to the default workflow of the user, or to the
+// built-in one.
+func (h *workflowHandler) DeleteWorkflow(context *gin.Context) {
+	userID, _ := context.Get("userID")
+
+	projectID, ok := h.findProject(context, userID.(uint64))
+	if !ok {
+		return
+	}
+
+	err := common.DB.Transaction(func(tx *gorm.DB) error {
+		return common.DeleteWorkflow(tx, userID.(uint64), projectID)
+	})
+	if err != nil {
+		zap.L().Error("Failed to delete workflow",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	zap.L().Info("Workflow deleted successfully",
+		zap.Uint64("user ID", userID.(uint64)),
+		zap.String("url path", context.Request.URL.Path),
+	)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Workflow deleted successfully"})
+}
+
+// findProject returns the project of the request, or nil on the routes of the
+// default workflow.
+func (h *workflowHandler) findProject(context *gin.Context, userID uint64) (*uint64, bool) {
+	if context.Param("id") == "" {
+		return nil, true
+	}
+
+	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("Invalid ID",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(err),
+		)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return nil, false
+	}
+
+	var project entities.Project
+	result := common.DB.Where("id = ? AND user_id = ?", id, userID).First(&project)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		zap.L().Error("Project not found",
+			zap.String("url path", context.Request.URL.Path),
+		)
+		context.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return nil, false
+	}
+	if result.Error != nil {
+		zap.L().Error("Failed to find project",
+			zap.String("url path", context.Request.URL.Path),
+			zap.Error(result.Error),
+		)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return nil, false
+	}
+
+	return &project.ID, true
+}
@@ -0,0 +1,216 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/internal/handlers"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+func setupWorkflowRouter() *gin.Engine {
+	todoHandler := handlers.NewTodoHandler()
+	workflowHandler := handlers.NewWorkflowHandler()
+
+	router := newUserRouter()
+	router.POST("/todo", todoHandler.CreateTodo)
+	router.PUT("/todo/:id", todoHandler.UpdateTodo)
+	router.GET("/workflow", workflowHandler.GetWorkflow)
+	router.PUT("/workflow", workflowHandler.SetWorkflow)
+	router.DELETE("/workflow", workflowHandler.DeleteWorkflow)
+	router.GET("/projects/:id/workflow", workflowHandler.GetWorkflow)
+	router.PUT("/projects/:id/workflow", workflowHandler.SetWorkflow)
+	router.DELETE("/projects/:id/workflow", workflowHandler.DeleteWorkflow)
+
+	return router
+}
+
+func TestStatusTransitions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.Todo{ID: 1, Description: "Test Todo", Status: "pending", UserID: 1})
+
+	router := setupWorkflowRouter()
+
+	update := func(status models.Status) models.TodoUpdateRequest {
+		return models.TodoUpdateRequest{Description: "Test Todo", Status: status}
+	}
+
+	tests := []struct {
+		name           string
+		body           models.TodoUpdateRequest
+		expectedStatus int
+		completed      bool
+	}{
+		{name: "Missing Status", body: update(""), expectedStatus: http.StatusBadRequest},
+		{name: "Unknown Status", body: update("done"), expectedStatus: http.StatusBadRequest},
+		{name: "Start", body: update(models.InProgress), expectedStatus: http.StatusOK},
+		{name: "Complete", body: update(models.Completed), expectedStatus: http.StatusOK, completed: true},
+		{name: "Keep Completed", body: update(models.Completed), expectedStatus: http.StatusOK, completed: true},
+		{name: "Restart Completed", body: update(models.InProgress), expectedStatus: http.StatusBadRequest, completed: true},
+		{name: "Reopen", body: update(models.Pending), expectedStatus: http.StatusOK},
+	}
+
+	var completedAt string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(router, 1, http.MethodPut, "/todo/1", tt.body)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var todo entities.Todo
+			common.DB.First(&todo, 1)
+			assert.Equal(t, tt.completed, todo.CompletedAt != nil)
+
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response models.TodoResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, string(tt.body.Status), response.Status)
+
+			// Completing again keeps the time it was completed
+			if tt.completed && completedAt != "" {
+				assert.Equal(t, completedAt, response.CompletedAt)
+			}
+			completedAt = response.CompletedAt
+		})
+	}
+}
+
+func TestCustomWorkflow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup test database
+	testDB := common.SetupTestDB()
+	common.SetDB(testDB) // Set the mock database for testing
+
+	common.DB.Create(&entities.Project{ID: 1, UserID: 1, Name: "Release"})
+	common.DB.Create(&entities.Project{ID: 2, UserID: 2, Name: "Other"})
+
+	router := setupWorkflowRouter()
+
+	review := models.Workflow{
+		States: []models.WorkflowState{
+			{Name: "todo", Category: models.Pending},
+			{Name: "doing", Category: models.InProgress},
+			{Name: "review", Category: models.InProgress},
+			{Name: "done", Category: models.Completed},
+		},
+		Transitions: []models.WorkflowTransition{
+			{From: "todo", To: "doing"},
+			{From: "doing", To: "review"},
+			{From: "review", To: "doing"},
+			{From: "review", To: "done"},
+		},
+	}
+	startsCompleted := models.Workflow{
+		States:      []models.WorkflowState{{Name: "done", Category: models.Completed}, {Name: "todo", Category: models.Pending}},
+		Transitions: []models.WorkflowTransition{{From: "todo", To: "done"}},
+	}
+	neverCompleted := models.Workflow{
+		States:      []models.WorkflowState{{Name: "todo", Category: models.Pending}, {Name: "doing", Category: models.InProgress}},
+		Transitions: []models.WorkflowTransition{{From: "todo", To: "doing"}},
+	}
+	duplicateState := models.Workflow{
+		States:      []models.WorkflowState{{Name: "todo", Category: models.Pending}, {Name: "todo", Category: models.Completed}},
+		Transitions: []models.WorkflowTransition{{From: "todo", To: "todo"}},
+	}
+	unknownState := models.Workflow{
+		States:      []models.WorkflowState{{Name: "todo", Category: models.Pending}, {Name: "done", Category: models.Completed}},
+		Transitions: []models.WorkflowTransition{{From: "todo", To: "finished"}},
+	}
+
+	setTests := []struct {
+		name           string
+		userID         uint64
+		path           string
+		body           models.Workflow
+		expectedStatus int
+	}{
+		{name: "Starts Completed", userID: 1, path: "/workflow", body: startsCompleted, expectedStatus: http.StatusBadRequest},
+		{name: "Never Completed", userID: 1, path: "/workflow", body: neverCompleted, expectedStatus: http.StatusBadRequest},
+		{name: "Duplicate State", userID: 1, path: "/workflow", body: duplicateState, expectedStatus: http.StatusBadRequest},
+		{name: "Unknown State", userID: 1, path: "/workflow", body: unknownState, expectedStatus: http.StatusBadRequest},
+		{name: "Missing States", userID: 1, path: "/workflow", body: models.Workflow{}, expectedStatus: http.StatusBadRequest},
+		{name: "Project Of Other User", userID: 1, path: "/projects/2/workflow", body: review, expectedStatus: http.StatusNotFound},
+		{name: "Project Workflow", userID: 1, path: "/projects/1/workflow", body: review, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range setTests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(router, tt.userID, http.MethodPut, tt.path, tt.body)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	readWorkflow := func(path string) models.WorkflowResponse {
+		w := serveAs(router, 1, http.MethodGet, path, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response models.WorkflowResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	// Only the project has a custom workflow
+	assert.Equal(t, models.WorkflowResponse{Workflow: models.DefaultWorkflow}, readWorkflow("/workflow"))
+	assert.Equal(t, models.WorkflowResponse{Workflow: review, Custom: true}, readWorkflow("/projects/1/workflow"))
+
+	projectID := uint64(1)
+	w := serveAs(router, 1, http.MethodPost, "/todo", models.TodoRequest{Description: "Ship it", ProjectID: &projectID})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var todo models.TodoResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todo))
+	assert.Equal(t, "todo", todo.Status)
+
+	w = serveAs(router, 1, http.MethodPost, "/todo", models.TodoRequest{Description: "Inbox todo"})
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todo))
+	assert.Equal(t, "pending", todo.Status)
+
+	move := func(status string) int {
+		w := serveAs(router, 1, http.MethodPut, "/todo/1", models.TodoUpdateRequest{
+			Description: "Ship it",
+			Status:      models.Status(status),
+			ProjectID:   &projectID,
+		})
+		todo = models.TodoResponse{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todo))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, move("completed"))
+	assert.Equal(t, http.StatusBadRequest, move("done"))
+	assert.Equal(t, http.StatusOK, move("doing"))
+	assert.Equal(t, http.StatusOK, move("review"))
+	assert.Equal(t, http.StatusOK, move("done"))
+	assert.NotEmpty(t, todo.CompletedAt)
+
+	// Without the project workflow the default of the user applies, and todos
+	// in states it doesn't know may take any of its states
+	w = serveAs(router, 1, http.MethodDelete, "/projects/1/workflow", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.WorkflowResponse{Workflow: models.DefaultWorkflow}, readWorkflow("/projects/1/workflow"))
+
+	w = serveAs(router, 1, http.MethodPut, "/workflow", review)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.WorkflowResponse{Workflow: review, Custom: true}, readWorkflow("/projects/1/workflow"))
+
+	assert.Equal(t, http.StatusOK, move("done"))
+
+	w = serveAs(router, 1, http.MethodDelete, "/workflow", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, http.StatusOK, move("in_progress"))
+	assert.Empty(t, todo.CompletedAt)
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_todos_user_id_due_at;
+
+ALTER TABLE
+    todos DROP COLUMN due_at,
+    DROP COLUMN remind_at;
@@ -0,0 +1,8 @@
+ALTER TABLE
+    todos
+ADD
+    COLUMN due_at TIMESTAMPTZ,
+ADD
+    COLUMN remind_at TIMESTAMPTZ;
+
+CREATE INDEX idx_todos_user_id_due_at ON todos (user_id, due_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_todos_remind_at;
+
+DROP TABLE IF EXISTS todo_reminders;
@@ -0,0 +1,12 @@
+CREATE TABLE todo_reminders (
+    id SERIAL PRIMARY KEY,
+    todo_id INT NOT NULL,
+    remind_at TIMESTAMPTZ NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_todo_id FOREIGN KEY (todo_id) REFERENCES todos (id) ON DELETE CASCADE,
+    CONSTRAINT uq_todo_reminders_todo_id_remind_at UNIQUE (todo_id, remind_at)
+);
+
+CREATE INDEX idx_todos_remind_at ON todos (remind_at)
+WHERE
+    remind_at IS NOT NULL;
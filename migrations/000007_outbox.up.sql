@@ -0,0 +1,15 @@
+CREATE TABLE outbox_events (
+    id SERIAL PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    message_key VARCHAR(255) NOT NULL,
+    value TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_outbox_events_unsent ON outbox_events (id)
+WHERE
+    sent_at IS NULL;
@@ -0,0 +1,8 @@
+ALTER TABLE
+    sessions
+ADD
+    COLUMN user_agent TEXT NOT NULL DEFAULT '',
+ADD
+    COLUMN ip_address VARCHAR(45) NOT NULL DEFAULT '',
+ADD
+    COLUMN last_seen_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,8 @@
+CREATE TABLE signing_keys (
+    kid VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(16) NOT NULL,
+    private_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    activates_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ
+);
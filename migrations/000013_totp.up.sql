@@ -0,0 +1,19 @@
+ALTER TABLE
+    users
+ADD
+    COLUMN totp_secret VARCHAR(64) NOT NULL DEFAULT '',
+ADD
+    COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT false,
+ADD
+    COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE
+    users DROP COLUMN verify_token_expires_at;
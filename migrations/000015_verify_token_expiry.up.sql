@@ -0,0 +1,19 @@
+ALTER TABLE
+    users
+ADD
+    COLUMN verify_token_expires_at TIMESTAMPTZ;
+
+-- Pending tokens get a day before they expire, used tokens are cleared
+UPDATE
+    users
+SET
+    verify_token_expires_at = CURRENT_TIMESTAMP + INTERVAL '24 hours'
+WHERE
+    verified = false;
+
+UPDATE
+    users
+SET
+    verify_token = ''
+WHERE
+    verified = true;
@@ -0,0 +1,8 @@
+ALTER TABLE
+    todos DROP COLUMN organization_id;
+
+DROP TABLE IF EXISTS organization_invitations;
+
+DROP TABLE IF EXISTS organization_members;
+
+DROP TABLE IF EXISTS organizations;
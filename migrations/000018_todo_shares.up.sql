@@ -0,0 +1,11 @@
+CREATE TABLE todo_shares (
+    todo_id INT NOT NULL,
+    user_id INT NOT NULL,
+    permission VARCHAR(16) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (todo_id, user_id),
+    CONSTRAINT fk_todo_id FOREIGN KEY (todo_id) REFERENCES todos (id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_todo_shares_user_id ON todo_shares (user_id);
@@ -0,0 +1,12 @@
+CREATE TABLE checklist_items (
+    id SERIAL PRIMARY KEY,
+    todo_id INT NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_todo_id FOREIGN KEY (todo_id) REFERENCES todos (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_checklist_items_todo_id ON checklist_items (todo_id);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS workflow_transitions;
+
+DROP TABLE IF EXISTS workflow_states;
+
+DROP TABLE IF EXISTS workflows;
+
+ALTER TABLE
+    todos DROP COLUMN completed_at;
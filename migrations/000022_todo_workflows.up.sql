@@ -0,0 +1,44 @@
+ALTER TABLE
+    todos
+ADD
+    COLUMN completed_at TIMESTAMPTZ;
+
+UPDATE
+    todos
+SET
+    completed_at = updated_at
+WHERE
+    status = 'completed';
+
+CREATE TABLE workflows (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    project_id INT UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_project_id FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+
+-- One default workflow per user, the others belong to a project
+CREATE UNIQUE INDEX idx_workflows_user_id ON workflows (user_id)
+WHERE
+    project_id IS NULL;
+
+CREATE TABLE workflow_states (
+    workflow_id INT NOT NULL,
+    name VARCHAR(32) NOT NULL,
+    category VARCHAR(16) NOT NULL,
+    position INT NOT NULL,
+    PRIMARY KEY (workflow_id, name),
+    CONSTRAINT fk_workflow_id FOREIGN KEY (workflow_id) REFERENCES workflows (id) ON DELETE CASCADE
+);
+
+CREATE TABLE workflow_transitions (
+    workflow_id INT NOT NULL,
+    from_state VARCHAR(32) NOT NULL,
+    to_state VARCHAR(32) NOT NULL,
+    position INT NOT NULL,
+    PRIMARY KEY (workflow_id, from_state, to_state),
+    CONSTRAINT fk_workflow_id FOREIGN KEY (workflow_id) REFERENCES workflows (id) ON DELETE CASCADE
+);
@@ -18,6 +18,36 @@ func (_m *AuthHandler) Authorize(context *gin.Context) {
 	_m.Called(context)
 }
 
+// ConfirmEmailChange provides a mock function with given fields: context
+func (_m *AuthHandler) ConfirmEmailChange(context *gin.Context) {
+	_m.Called(context)
+}
+
+// DisableTOTP provides a mock function with given fields: context
+func (_m *AuthHandler) DisableTOTP(context *gin.Context) {
+	_m.Called(context)
+}
+
+// EnableTOTP provides a mock function with given fields: context
+func (_m *AuthHandler) EnableTOTP(context *gin.Context) {
+	_m.Called(context)
+}
+
+// EnrollTOTP provides a mock function with given fields: context
+func (_m *AuthHandler) EnrollTOTP(context *gin.Context) {
+	_m.Called(context)
+}
+
+// ForgotPassword provides a mock function with given fields: context
+func (_m *AuthHandler) ForgotPassword(context *gin.Context) {
+	_m.Called(context)
+}
+
+// JWKS provides a mock function with given fields: context
+func (_m *AuthHandler) JWKS(context *gin.Context) {
+	_m.Called(context)
+}
+
 // Login provides a mock function with given fields: context
 func (_m *AuthHandler) Login(context *gin.Context) {
 	_m.Called(context)
@@ -28,16 +58,41 @@ func (_m *AuthHandler) Logout(context *gin.Context) {
 	_m.Called(context)
 }
 
+// Refresh provides a mock function with given fields: context
+func (_m *AuthHandler) Refresh(context *gin.Context) {
+	_m.Called(context)
+}
+
 // Register provides a mock function with given fields: context
 func (_m *AuthHandler) Register(context *gin.Context) {
 	_m.Called(context)
 }
 
+// ResendVerification provides a mock function with given fields: context
+func (_m *AuthHandler) ResendVerification(context *gin.Context) {
+	_m.Called(context)
+}
+
+// ResetPassword provides a mock function with given fields: context
+func (_m *AuthHandler) ResetPassword(context *gin.Context) {
+	_m.Called(context)
+}
+
+// Unlock provides a mock function with given fields: context
+func (_m *AuthHandler) Unlock(context *gin.Context) {
+	_m.Called(context)
+}
+
 // Verify provides a mock function with given fields: context
 func (_m *AuthHandler) Verify(context *gin.Context) {
 	_m.Called(context)
 }
 
+// VerifyMFA provides a mock function with given fields: context
+func (_m *AuthHandler) VerifyMFA(context *gin.Context) {
+	_m.Called(context)
+}
+
 // NewAuthHandler creates a new instance of AuthHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewAuthHandler(t interface {
@@ -2,7 +2,15 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	common "github.com/whitehead421/todo-backend/pkg/common"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
 
 // ICommon is an autogenerated mock type for the ICommon type
 type ICommon struct {
@@ -27,9 +35,9 @@ func (_m *ICommon) CheckPasswordHash(password string, hash string) bool {
 	return r0
 }
 
-// CreateToken provides a mock function with given fields: id
-func (_m *ICommon) CreateToken(id uint64) (string, error) {
-	ret := _m.Called(id)
+// CreateToken provides a mock function with given fields: id, sessionID, ttl
+func (_m *ICommon) CreateToken(id uint64, sessionID string, ttl time.Duration) (string, error) {
+	ret := _m.Called(id, sessionID, ttl)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateToken")
@@ -37,17 +45,45 @@ func (_m *ICommon) CreateToken(id uint64) (string, error) {
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(uint64) (string, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(uint64, string, time.Duration) (string, error)); ok {
+		return rf(id, sessionID, ttl)
 	}
-	if rf, ok := ret.Get(0).(func(uint64) string); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(uint64, string, time.Duration) string); ok {
+		r0 = rf(id, sessionID, ttl)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(uint64) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(uint64, string, time.Duration) error); ok {
+		r1 = rf(id, sessionID, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateSecureToken provides a mock function with given fields:
+func (_m *ICommon) GenerateSecureToken() (string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateSecureToken")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -91,23 +127,73 @@ func (_m *ICommon) HashPassword(password string) string {
 	return r0
 }
 
+// HashToken provides a mock function with given fields: token
+func (_m *ICommon) HashToken(token string) string {
+	ret := _m.Called(token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HashToken")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// ParseToken provides a mock function with given fields: tokenString, keyFunc
+func (_m *ICommon) ParseToken(tokenString string, keyFunc jwt.Keyfunc) (*common.TokenClaims, error) {
+	ret := _m.Called(tokenString, keyFunc)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ParseToken")
+	}
+
+	var r0 *common.TokenClaims
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, jwt.Keyfunc) (*common.TokenClaims, error)); ok {
+		return rf(tokenString, keyFunc)
+	}
+	if rf, ok := ret.Get(0).(func(string, jwt.Keyfunc) *common.TokenClaims); ok {
+		r0 = rf(tokenString, keyFunc)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.TokenClaims)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, jwt.Keyfunc) error); ok {
+		r1 = rf(tokenString, keyFunc)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ValidateToken provides a mock function with given fields: tokenString
-func (_m *ICommon) ValidateToken(tokenString string) (uint64, error) {
+func (_m *ICommon) ValidateToken(tokenString string) (*common.TokenClaims, error) {
 	ret := _m.Called(tokenString)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ValidateToken")
 	}
 
-	var r0 uint64
+	var r0 *common.TokenClaims
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (uint64, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (*common.TokenClaims, error)); ok {
 		return rf(tokenString)
 	}
-	if rf, ok := ret.Get(0).(func(string) uint64); ok {
+	if rf, ok := ret.Get(0).(func(string) *common.TokenClaims); ok {
 		r0 = rf(tokenString)
 	} else {
-		r0 = ret.Get(0).(uint64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.TokenClaims)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
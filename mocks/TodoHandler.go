@@ -23,6 +23,16 @@ func (_m *TodoHandler) DeleteTodo(context *gin.Context) {
 	_m.Called(context)
 }
 
+// ListTodos provides a mock function with given fields: context
+func (_m *TodoHandler) ListTodos(context *gin.Context) {
+	_m.Called(context)
+}
+
+// PatchTodo provides a mock function with given fields: context
+func (_m *TodoHandler) PatchTodo(context *gin.Context) {
+	_m.Called(context)
+}
+
 // ReadTodo provides a mock function with given fields: context
 func (_m *TodoHandler) ReadTodo(context *gin.Context) {
 	_m.Called(context)
@@ -13,6 +13,11 @@ type UserHandler struct {
 	mock.Mock
 }
 
+// ChangeEmail provides a mock function with given fields: context
+func (_m *UserHandler) ChangeEmail(context *gin.Context) {
+	_m.Called(context)
+}
+
 // ChangePassword provides a mock function with given fields: context
 func (_m *UserHandler) ChangePassword(context *gin.Context) {
 	_m.Called(context)
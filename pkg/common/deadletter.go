@@ -0,0 +1,104 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Headers attached to dead letters, all sharing the prefix so a replay can
+// strip them again.
+const (
+	deadLetterHeaderPrefix    = "dlq-"
+	deadLetterErrorHeader     = "dlq-error"
+	deadLetterAttemptsHeader  = "dlq-attempts"
+	deadLetterTopicHeader     = "dlq-topic"
+	deadLetterPartitionHeader = "dlq-partition"
+	deadLetterOffsetHeader    = "dlq-offset"
+	deadLetterFailedAtHeader  = "dlq-failed-at"
+)
+
+// WriteDeadLetter copies a message that couldn't be handled to the
+// dead-letter topic, with the failure reason attached as headers.
+func WriteDeadLetter(ctx context.Context, writer KafkaWriter, msg kafka.Message, attempts int, reason error) error {
+	headers := append(stripDeadLetterHeaders(msg.Headers),
+		kafka.Header{Key: deadLetterErrorHeader, Value: []byte(reason.Error())},
+		kafka.Header{Key: deadLetterAttemptsHeader, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: deadLetterTopicHeader, Value: []byte(msg.Topic)},
+		kafka.Header{Key: deadLetterPartitionHeader, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: deadLetterOffsetHeader, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: deadLetterFailedAtHeader, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+
+	return writer.WriteMessages(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+}
+
+// ReplayDeadLetters moves messages from the dead-letter topic back onto the
+// main topic. It stops after limit messages, or when no message arrived
+// within idle. A limit of 0 replays everything.
+func ReplayDeadLetters(ctx context.Context, reader KafkaReader, writer KafkaWriter, limit int, idle time.Duration) (int, error) {
+	replayed := 0
+
+	for limit == 0 || replayed < limit {
+		fetchCtx, cancel := context.WithTimeout(ctx, idle)
+		msg, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				return replayed, nil
+			}
+			return replayed, err
+		}
+
+		err = writer.WriteMessages(ctx, kafka.Message{
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: stripDeadLetterHeaders(msg.Headers),
+		})
+		if err != nil {
+			return replayed, err
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			return replayed, err
+		}
+
+		zap.L().Info("Replayed dead letter",
+			zap.Int64("offset", msg.Offset),
+			zap.String("reason", deadLetterHeader(msg, deadLetterErrorHeader)),
+		)
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+func stripDeadLetterHeaders(headers []kafka.Header) []kafka.Header {
+	stripped := make([]kafka.Header, 0, len(headers))
+	for _, header := range headers {
+		if !strings.HasPrefix(header.Key, deadLetterHeaderPrefix) {
+			stripped = append(stripped, header)
+		}
+	}
+
+	return stripped
+}
+
+func deadLetterHeader(msg kafka.Message, key string) string {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,195 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+func headerValue(msg kafka.Message, key string) string {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
+
+func TestConsumeEventsMovesFailedEventsToDeadLetterTopic(t *testing.T) {
+	tests := []struct {
+		name             string
+		handlerErr       error
+		expectedAttempts string
+		expectedCalls    int
+	}{
+		{
+			name:             "Retried Until Max Attempts",
+			handlerErr:       errors.New("mail server is down"),
+			expectedAttempts: "5",
+			expectedCalls:    5,
+		},
+		{
+			name:             "Permanent Failure",
+			handlerErr:       common.Permanent(errors.New("payload can't be decoded")),
+			expectedAttempts: "1",
+			expectedCalls:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			skipRetryDelays(t)
+
+			db, mockRedis := redismock.NewClientMock()
+			common.SetRedisClient(db)
+
+			event, msg := newEventMessage(t, models.UserRegistered)
+			msg.Topic = "events"
+			msg.Partition = 2
+			msg.Offset = 42
+
+			mockRedis.ExpectExists("event_handled:" + event.ID).SetVal(0)
+
+			calls := 0
+			dispatcher := common.NewEventDispatcher()
+			dispatcher.Register(models.UserRegistered, func(ctx context.Context, event models.Event) error {
+				calls++
+				return test.handlerErr
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			reader := &fakeReader{messages: []kafka.Message{msg}, cancel: cancel}
+			deadLetters := &fakeWriter{}
+
+			common.ConsumeEvents(reader, ctx, dispatcher, deadLetters)
+
+			assert.Equal(t, test.expectedCalls, calls)
+			assert.Len(t, reader.committed, 1)
+			if assert.Len(t, deadLetters.messages, 1) {
+				deadLetter := deadLetters.messages[0]
+				assert.Equal(t, msg.Key, deadLetter.Key)
+				assert.Equal(t, msg.Value, deadLetter.Value)
+				assert.Equal(t, string(models.UserRegistered), headerValue(deadLetter, "event-type"))
+				assert.Equal(t, test.handlerErr.Error(), headerValue(deadLetter, "dlq-error"))
+				assert.Equal(t, test.expectedAttempts, headerValue(deadLetter, "dlq-attempts"))
+				assert.Equal(t, "events", headerValue(deadLetter, "dlq-topic"))
+				assert.Equal(t, "2", headerValue(deadLetter, "dlq-partition"))
+				assert.Equal(t, "42", headerValue(deadLetter, "dlq-offset"))
+			}
+
+			if err := mockRedis.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestConsumeEventsMovesUndecodableMessagesToDeadLetterTopic(t *testing.T) {
+	skipRetryDelays(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msg := kafka.Message{Key: []byte("1"), Value: []byte("not an event")}
+	reader := &fakeReader{messages: []kafka.Message{msg}, cancel: cancel}
+	deadLetters := &fakeWriter{}
+
+	common.ConsumeEvents(reader, ctx, common.NewEventDispatcher(), deadLetters)
+
+	assert.Len(t, reader.committed, 1)
+	if assert.Len(t, deadLetters.messages, 1) {
+		assert.Equal(t, "1", headerValue(deadLetters.messages[0], "dlq-attempts"))
+	}
+}
+
+func TestConsumeEventsCommitsOnlyOnceDeadLetterIsWritten(t *testing.T) {
+	delays := skipRetryDelays(t)
+
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+
+	event, msg := newEventMessage(t, models.UserRegistered)
+	mockRedis.ExpectExists("event_handled:" + event.ID).SetVal(0)
+
+	dispatcher := common.NewEventDispatcher()
+	dispatcher.Register(models.UserRegistered, func(ctx context.Context, event models.Event) error {
+		return common.Permanent(errors.New("payload can't be decoded"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reader := &fakeReader{messages: []kafka.Message{msg}, cancel: cancel}
+	deadLetters := &fakeWriter{failures: 3}
+
+	common.ConsumeEvents(reader, ctx, dispatcher, deadLetters)
+
+	assert.Equal(t, 4, deadLetters.writes)
+	assert.Len(t, *delays, 3)
+	assert.Len(t, deadLetters.messages, 1)
+	assert.Len(t, reader.committed, 1)
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	deadLetter := func(offset int64) kafka.Message {
+		return kafka.Message{
+			Offset: offset,
+			Key:    []byte("1"),
+			Value:  []byte(`{"type":"user.registered"}`),
+			Headers: []kafka.Header{
+				{Key: "event-type", Value: []byte(models.UserRegistered)},
+				{Key: "dlq-error", Value: []byte("mail server is down")},
+				{Key: "dlq-attempts", Value: []byte("5")},
+			},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		limit            int
+		expectedReplayed int
+	}{
+		{
+			name:             "Everything",
+			limit:            0,
+			expectedReplayed: 3,
+		},
+		{
+			name:             "Limited",
+			limit:            2,
+			expectedReplayed: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Without cancelling, the reader runs dry until the idle timeout
+			reader := &fakeReader{
+				messages: []kafka.Message{deadLetter(1), deadLetter(2), deadLetter(3)},
+				cancel:   func() {},
+			}
+			writer := &fakeWriter{}
+
+			replayed, err := common.ReplayDeadLetters(context.Background(), reader, writer, test.limit, 10*time.Millisecond)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedReplayed, replayed)
+			assert.Len(t, reader.committed, test.expectedReplayed)
+			if assert.Len(t, writer.messages, test.expectedReplayed) {
+				assert.Equal(t, []kafka.Header{
+					{Key: "event-type", Value: []byte(models.UserRegistered)},
+				}, writer.messages[0].Headers)
+			}
+		})
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
@@ -15,14 +18,37 @@ type Environment struct {
 	AuthPort         string
 	NotificationPort string
 	DatabaseDsn      string
-	JwtSecret        string
+	JwtPrivateKey    string
+	JwksCacheTTL     string
+	TotpIssuer       string
+	AccessTokenTTL   string
+	RefreshTokenTTL  string
+	VerifyTokenTTL   string
+	VerifyResendWait string
+	LoginMaxFailures string
+	LoginIPFailures  string
+	LoginWindow      string
+	LoginLockout     string
+	LoginDelay       string
+	LoginMaxDelay    string
+	TrustedProxies   string
 	RedisAddr        string
 	KafkaBrokers     string
 	KafkaTopic       string
 	KafkaGroupID     string
+	KafkaDLQTopic    string
+	MailBackend      string
 	MailjetSecretKey string
 	MailjetAPIKey    string
+	SmtpAddr         string
+	SmtpUsername     string
+	SmtpPassword     string
+	MailDir          string
 	SenderEmail      string
+	AuthBaseURL      string
+	FrontendBaseURL  string
+	ReminderInterval string
+	OutboxInterval   string
 }
 
 func ParseVariable(key string, required bool, defaultValue string) string {
@@ -36,6 +62,33 @@ func ParseVariable(key string, required bool, defaultValue string) string {
 	return value
 }
 
+func ParseDuration(key, value string) time.Duration {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Panicf("Environment variable is not a duration: %s", key)
+	}
+	return duration
+}
+
+func ParseInt(key, value string) int64 {
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Panicf("Environment variable is not a number: %s", key)
+	}
+	return number
+}
+
+// ParseList splits a comma separated variable, leaving out empty items.
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func GetEnvironmentVariables() *Environment {
 	const projectDirName = "todo-backend"
 
@@ -58,13 +111,36 @@ func GetEnvironmentVariables() *Environment {
 		AuthPort:         ParseVariable("AUTH_PORT", false, "8081"),
 		NotificationPort: ParseVariable("NOTIFICATION_PORT", false, "8082"),
 		DatabaseDsn:      ParseVariable("DATABASE_DSN", true, ""),
-		JwtSecret:        ParseVariable("JWT_SECRET", true, ""),
+		JwtPrivateKey:    ParseVariable("JWT_PRIVATE_KEY", false, ""),
+		JwksCacheTTL:     ParseVariable("JWKS_CACHE_TTL", false, "10m"),
+		TotpIssuer:       ParseVariable("TOTP_ISSUER", false, "Todo"),
+		AccessTokenTTL:   ParseVariable("ACCESS_TOKEN_TTL", false, "15m"),
+		RefreshTokenTTL:  ParseVariable("REFRESH_TOKEN_TTL", false, "720h"),
+		VerifyTokenTTL:   ParseVariable("VERIFY_TOKEN_TTL", false, "24h"),
+		VerifyResendWait: ParseVariable("VERIFY_RESEND_INTERVAL", false, "1m"),
+		LoginMaxFailures: ParseVariable("LOGIN_MAX_FAILURES", false, "5"),
+		LoginIPFailures:  ParseVariable("LOGIN_IP_MAX_FAILURES", false, "50"),
+		LoginWindow:      ParseVariable("LOGIN_FAILURE_WINDOW", false, "15m"),
+		LoginLockout:     ParseVariable("LOGIN_LOCKOUT_DURATION", false, "15m"),
+		LoginDelay:       ParseVariable("LOGIN_DELAY", false, "250ms"),
+		LoginMaxDelay:    ParseVariable("LOGIN_MAX_DELAY", false, "4s"),
+		TrustedProxies:   ParseVariable("TRUSTED_PROXIES", false, ""),
 		RedisAddr:        ParseVariable("REDIS_ADDR", true, ""),
 		KafkaBrokers:     ParseVariable("KAFKA_BROKERS", true, ""),
 		KafkaTopic:       ParseVariable("KAFKA_TOPIC", true, ""),
 		KafkaGroupID:     ParseVariable("KAFKA_GROUP_ID", true, ""),
-		MailjetSecretKey: ParseVariable("MAIL_SECRET", true, ""),
-		MailjetAPIKey:    ParseVariable("MAIL_API_KEY", true, ""),
+		KafkaDLQTopic:    ParseVariable("KAFKA_DLQ_TOPIC", false, "notifications-dlq"),
+		MailBackend:      ParseVariable("MAIL_BACKEND", false, "mailjet"),
+		MailjetSecretKey: ParseVariable("MAIL_SECRET", false, ""),
+		MailjetAPIKey:    ParseVariable("MAIL_API_KEY", false, ""),
+		SmtpAddr:         ParseVariable("SMTP_ADDR", false, "localhost:1025"),
+		SmtpUsername:     ParseVariable("SMTP_USERNAME", false, ""),
+		SmtpPassword:     ParseVariable("SMTP_PASSWORD", false, ""),
+		MailDir:          ParseVariable("MAIL_DIR", false, "mail"),
 		SenderEmail:      ParseVariable("SENDER_EMAIL", true, ""),
+		AuthBaseURL:      ParseVariable("AUTH_BASE_URL", false, "http://localhost:8081"),
+		FrontendBaseURL:  ParseVariable("FRONTEND_BASE_URL", false, "http://localhost:3000"),
+		ReminderInterval: ParseVariable("REMINDER_INTERVAL", false, "1m"),
+		OutboxInterval:   ParseVariable("OUTBOX_INTERVAL", false, "1s"),
 	}
 }
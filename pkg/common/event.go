@@ -0,0 +1,201 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+)
+
+// EventSchemaVersion is the payload version written by this build.
+const EventSchemaVersion = 1
+
+const eventTypeHeader = "event-type"
+
+// eventHandledTTL is how long handled event IDs are remembered, longer than
+// redeliveries of the outbox relay or Kafka can happen.
+const eventHandledTTL = 7 * 24 * time.Hour
+
+var ErrUnknownEventType = errors.New("unknown event type")
+
+type EventHandler func(ctx context.Context, event models.Event) error
+
+// EventDispatcher routes events read from Kafka to the handler registered
+// for their type.
+type EventDispatcher struct {
+	handlers map[models.EventType]EventHandler
+}
+
+func NewEventDispatcher() *EventDispatcher {
+	return &EventDispatcher{
+		handlers: make(map[models.EventType]EventHandler),
+	}
+}
+
+func (d *EventDispatcher) Register(eventType models.EventType, handler EventHandler) {
+	d.handlers[eventType] = handler
+}
+
+// Dispatch runs the handler of the event type. Unknown types fail
+// permanently, they end up in the dead-letter topic and can be replayed once
+// a handler is deployed.
+func (d *EventDispatcher) Dispatch(ctx context.Context, event models.Event) error {
+	handler, ok := d.handlers[event.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("%w: %s", ErrUnknownEventType, event.Type))
+	}
+
+	return handler(ctx, event)
+}
+
+func NewEvent(eventType models.EventType, payload interface{}) (models.Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	return models.Event{
+		ID:            GenerateUUID(),
+		Type:          eventType,
+		SchemaVersion: EventSchemaVersion,
+		OccurredAt:    time.Now().UTC(),
+		Payload:       data,
+	}, nil
+}
+
+// NewEventMessage builds the Kafka message for an encoded event. The writers
+// hash the key to pick the partition, so events about the same entity keep
+// their order.
+func NewEventMessage(eventType models.EventType, key string, value []byte) kafka.Message {
+	return kafka.Message{
+		Key:   []byte(key),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: eventTypeHeader, Value: []byte(eventType)},
+		},
+	}
+}
+
+func DecodeEvent(msg kafka.Message) (models.Event, error) {
+	var event models.Event
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		return event, err
+	}
+
+	if event.Type == "" {
+		return event, errors.New("event type is missing")
+	}
+
+	return event, nil
+}
+
+// DecodePayload unmarshals the payload of an event, refusing schema versions
+// newer than this build understands.
+func DecodePayload(event models.Event, payload interface{}) error {
+	if event.SchemaVersion > EventSchemaVersion {
+		return Permanent(fmt.Errorf("unsupported schema version %d for %s", event.SchemaVersion, event.Type))
+	}
+
+	if err := json.Unmarshal(event.Payload, payload); err != nil {
+		return Permanent(err)
+	}
+
+	return nil
+}
+
+// ConsumeEvents reads events from Kafka and hands them to the dispatcher
+// until ctx is cancelled. A message is only committed once it was handled or
+// moved to the dead-letter topic, so nothing is dropped on failure.
+func ConsumeEvents(reader KafkaReader, ctx context.Context, dispatcher *EventDispatcher, deadLetterWriter KafkaWriter) {
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			zap.L().Error("Failed to read message from Kafka", zap.Error(err))
+			continue
+		}
+
+		attempts, handleErr := handleMessage(ctx, msg, dispatcher)
+		if handleErr != nil {
+			if ctx.Err() != nil {
+				// Shutting down, the uncommitted message is delivered again
+				return
+			}
+
+			zap.L().Error("Failed to handle event, moving it to the dead-letter topic",
+				zap.Int64("offset", msg.Offset),
+				zap.Int("attempts", attempts),
+				zap.Error(handleErr),
+			)
+
+			_, err = retryWithBackoff(ctx, 0, func() error {
+				return WriteDeadLetter(ctx, deadLetterWriter, msg, attempts, handleErr)
+			})
+			if err != nil {
+				return
+			}
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			zap.L().Error("Failed to commit message",
+				zap.Int64("offset", msg.Offset),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+func handleMessage(ctx context.Context, msg kafka.Message, dispatcher *EventDispatcher) (int, error) {
+	event, err := DecodeEvent(msg)
+	if err != nil {
+		return 1, Permanent(err)
+	}
+
+	// Delivery is at-least-once, so events that were handled before are skipped
+	handled, err := RedisClient.Exists(ctx, eventHandledKey(event.ID)).Result()
+	if err != nil {
+		zap.L().Error("Failed to check if event was handled",
+			zap.String("event ID", event.ID),
+			zap.Error(err),
+		)
+	}
+	if handled > 0 {
+		zap.L().Info("Skipped event that was already handled",
+			zap.String("event ID", event.ID),
+			zap.String("event type", string(event.Type)),
+		)
+		return 0, nil
+	}
+
+	attempts, err := retryWithBackoff(ctx, eventMaxAttempts, func() error {
+		return dispatcher.Dispatch(ctx, event)
+	})
+	if err != nil {
+		return attempts, err
+	}
+
+	if err := RedisClient.Set(ctx, eventHandledKey(event.ID), 1, eventHandledTTL).Err(); err != nil {
+		zap.L().Error("Failed to remember handled event",
+			zap.String("event ID", event.ID),
+			zap.Error(err),
+		)
+	}
+
+	zap.L().Info("Handled event",
+		zap.String("event ID", event.ID),
+		zap.String("event type", string(event.Type)),
+	)
+
+	return attempts, nil
+}
+
+func eventHandledKey(eventID string) string {
+	return fmt.Sprintf("event_handled:%s", eventID)
+}
@@ -0,0 +1,112 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+// fakeReader hands out its messages and cancels the consumer once they are
+// all read.
+type fakeReader struct {
+	messages  []kafka.Message
+	committed []kafka.Message
+	cancel    context.CancelFunc
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if len(r.messages) == 0 {
+		r.cancel()
+		<-ctx.Done()
+		return kafka.Message{}, ctx.Err()
+	}
+
+	msg := r.messages[0]
+	r.messages = r.messages[1:]
+	return msg, nil
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	return nil
+}
+
+// fakeWriter fails its first failures writes.
+type fakeWriter struct {
+	messages []kafka.Message
+	failures int
+	writes   int
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.writes++
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("kafka is unavailable")
+	}
+
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	return nil
+}
+
+func newEventMessage(t *testing.T, eventType models.EventType) (models.Event, kafka.Message) {
+	event, err := common.NewEvent(eventType, map[string]string{"email": "user@example.com"})
+	assert.NoError(t, err)
+
+	value, err := json.Marshal(event)
+	assert.NoError(t, err)
+
+	return event, common.NewEventMessage(eventType, "1", value)
+}
+
+func TestConsumeEventsSkipsHandledEvents(t *testing.T) {
+	db, mockRedis := redismock.NewClientMock()
+	common.SetRedisClient(db)
+
+	event, msg := newEventMessage(t, models.UserRegistered)
+	key := "event_handled:" + event.ID
+
+	// The relay published the event twice
+	mockRedis.ExpectExists(key).SetVal(0)
+	mockRedis.ExpectSet(key, 1, 7*24*time.Hour).SetVal("OK")
+	mockRedis.ExpectExists(key).SetVal(1)
+
+	var handled int
+	dispatcher := common.NewEventDispatcher()
+	dispatcher.Register(models.UserRegistered, func(ctx context.Context, event models.Event) error {
+		handled++
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reader := &fakeReader{messages: []kafka.Message{msg, msg}, cancel: cancel}
+	deadLetters := &fakeWriter{}
+
+	common.ConsumeEvents(reader, ctx, dispatcher, deadLetters)
+
+	assert.Equal(t, 1, handled)
+	assert.Len(t, reader.committed, 2)
+	assert.Empty(t, deadLetters.messages)
+
+	if err := mockRedis.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,22 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+var RetryWithBackoff = retryWithBackoff
+
+// SetRetryAfter replaces the wait between retries until the test ends.
+func SetRetryAfter(t *testing.T, after func(time.Duration) <-chan time.Time) {
+	previous := retryAfter
+	retryAfter = after
+	t.Cleanup(func() { retryAfter = previous })
+}
+
+// Aliases of unexported types, for the tests of package common_test.
+type (
+	MailjetMailer = mailjetMailer
+	SMTPMailer    = smtpMailer
+	FileMailer    = fileMailer
+)
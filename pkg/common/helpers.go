@@ -1,6 +1,10 @@
 package common
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -12,12 +16,20 @@ import (
 type ICommon interface {
 	HashPassword(password string) string
 	CheckPasswordHash(password, hash string) bool
-	CreateToken(id uint64) (string, error)
-	ValidateToken(tokenString string) (id uint64, err error)
+	CreateToken(id uint64, sessionID string, ttl time.Duration) (string, error)
+	ValidateToken(tokenString string) (claims *TokenClaims, err error)
+	ParseToken(tokenString string, keyFunc jwt.Keyfunc) (claims *TokenClaims, err error)
 	GenerateUUID() string
+	GenerateSecureToken() (string, error)
+	HashToken(token string) string
 }
 
-var secretKey = []byte(GetEnvironmentVariables().JwtSecret)
+// TokenClaims are the claims of an access token. SessionID ties the token to
+// the login session it was issued for.
+type TokenClaims struct {
+	UserID    uint64
+	SessionID string
+}
 
 func HashPassword(password string) string {
 	bytes, _ := bcrypt.GenerateFromPassword([]byte(password), 14)
@@ -29,14 +41,21 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-func CreateToken(id uint64) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
+func CreateToken(id uint64, sessionID string, ttl time.Duration) (string, error) {
+	signingKey, err := currentSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(signingKey.Algorithm),
 		jwt.MapClaims{
 			"id":  id,
-			"exp": time.Now().Add(time.Hour).Unix(),
+			"sid": sessionID,
+			"exp": time.Now().Add(ttl).Unix(),
 		})
+	token.Header["kid"] = signingKey.ID
 
-	tokenString, err := token.SignedString(secretKey)
+	tokenString, err := token.SignedString(signingKey.Private)
 	if err != nil {
 		return "", err
 	}
@@ -44,25 +63,59 @@ func CreateToken(id uint64) (string, error) {
 	return tokenString, nil
 }
 
-func ValidateToken(tokenString string) (id uint64, err error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return secretKey, nil
-	})
+// ValidateToken verifies a token with the keyring of this service.
+func ValidateToken(tokenString string) (claims *TokenClaims, err error) {
+	return ParseToken(tokenString, signingKeyFunc)
+}
+
+// ParseToken verifies a token with the keys returned by keyFunc.
+func ParseToken(tokenString string, keyFunc jwt.Keyfunc) (claims *TokenClaims, err error) {
+	token, err := jwt.Parse(tokenString, keyFunc, jwt.WithValidMethods([]string{
+		jwt.SigningMethodEdDSA.Alg(),
+		jwt.SigningMethodRS256.Alg(),
+	}))
 	if err != nil {
 		return
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		idFloat, ok := claims["id"].(float64)
-		if !ok {
-			return 0, errors.New("id is not a float64")
-		}
-		return uint64(idFloat), nil
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("token is not valid")
+	}
+
+	idFloat, ok := mapClaims["id"].(float64)
+	if !ok {
+		return nil, errors.New("id is not a float64")
 	}
 
-	return
+	sessionID, ok := mapClaims["sid"].(string)
+	if !ok {
+		return nil, errors.New("sid is not a string")
+	}
+
+	return &TokenClaims{
+		UserID:    uint64(idFloat),
+		SessionID: sessionID,
+	}, nil
 }
 
 func GenerateUUID() string {
 	return uuid.New().String()
 }
+
+// GenerateSecureToken returns a random URL safe token, for tokens sent to users.
+func GenerateSecureToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// HashToken hashes a token for storage. Tokens are long and random, so
+// unlike passwords a fast hash is enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,296 @@
+package common
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// Access tokens are signed by the auth service with an asymmetric key. The
+// public half is published as a JSON Web Key Set, so other services verify
+// tokens without calling the auth service.
+
+var (
+	ErrNoSigningKey   = errors.New("signing key is not initialized")
+	ErrUnknownKey     = errors.New("unknown signing key")
+	errUnsupportedKey = errors.New("unsupported key type")
+)
+
+// jwksMinRefresh limits how often an unknown kid triggers a fetch of the key set.
+const jwksMinRefresh = 10 * time.Second
+
+type SigningKey struct {
+	ID          string
+	Algorithm   string
+	Private     crypto.Signer
+	ActivatesAt time.Time
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func GenerateSigningKey() (*SigningKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSigningKey(private)
+}
+
+// LoadSigningKey reads a PEM encoded Ed25519 or RSA private key file.
+func LoadSigningKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSigningKey(data)
+}
+
+func ParseSigningKey(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var private interface{}
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errUnsupportedKey
+	}
+
+	return NewSigningKey(signer)
+}
+
+func NewSigningKey(private crypto.Signer) (*SigningKey, error) {
+	jwk, err := NewJWK(private.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		ID:        jwk.KeyID,
+		Algorithm: jwk.Algorithm,
+		Private:   private,
+	}, nil
+}
+
+// NewJWK describes a public key as a JWK. The kid is the RFC 7638 thumbprint
+// of the key, so it changes only when the key does.
+func NewJWK(public crypto.PublicKey) (JWK, error) {
+	var jwk JWK
+	var canonical string
+
+	switch key := public.(type) {
+	case ed25519.PublicKey:
+		jwk = JWK{
+			KeyType:   "OKP",
+			Algorithm: jwt.SigningMethodEdDSA.Alg(),
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key),
+		}
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s"}`, jwk.Curve, jwk.KeyType, jwk.X)
+	case *rsa.PublicKey:
+		jwk = JWK{
+			KeyType:   "RSA",
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"%s","n":"%s"}`, jwk.E, jwk.KeyType, jwk.N)
+	default:
+		return JWK{}, errUnsupportedKey
+	}
+
+	thumbprint := sha256.Sum256([]byte(canonical))
+	jwk.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+	jwk.Use = "sig"
+
+	return jwk, nil
+}
+
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if k.Curve != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errUnsupportedKey
+		}
+		return ed25519.PublicKey(x), nil
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	default:
+		return nil, errUnsupportedKey
+	}
+}
+
+// EncodePrivateKey encodes the private key as PKCS #8 PEM.
+func (k *SigningKey) EncodePrivateKey() (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.Private)
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// JWKSCache keeps the key set of the auth service. Keys are fetched again
+// when they are older than the TTL or a token has an unknown kid. If the auth
+// service is unreachable, the keys already fetched keep being used.
+type JWKSCache struct {
+	url       string
+	ttl       time.Duration
+	client    *http.Client
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	refreshes singleflight.Group
+}
+
+func NewJWKSCache(url string, ttl time.Duration) *JWKSCache {
+	return &JWKSCache{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 5 * time.Second},
+		keys:   map[string]crypto.PublicKey{},
+	}
+}
+
+// Keyfunc looks up the verification key of a token, for jwt.Parse. Only
+// tokens with an unknown kid wait for a fetch, stale keys are refreshed in
+// the background.
+func (c *JWKSCache) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	stale := time.Since(c.fetchedAt) > c.ttl
+	c.mu.RUnlock()
+
+	switch {
+	case !ok:
+		c.refresh()
+
+		c.mu.RLock()
+		key, ok = c.keys[kid]
+		c.mu.RUnlock()
+	case stale:
+		go c.refresh()
+	}
+
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+// refresh fetches the key set unless that was tried recently. Concurrent
+// callers share one fetch, which runs without holding the lock.
+func (c *JWKSCache) refresh() {
+	_, _, _ = c.refreshes.Do(c.url, func() (interface{}, error) {
+		c.mu.Lock()
+		if time.Since(c.fetchedAt) <= jwksMinRefresh {
+			c.mu.Unlock()
+			return nil, nil
+		}
+		// Failed attempts count too, so an unreachable auth service isn't hammered
+		c.fetchedAt = time.Now()
+		c.mu.Unlock()
+
+		keys, err := c.fetch()
+		if err != nil {
+			zap.L().Error("Failed to fetch JWKS", zap.String("url", c.url), zap.Error(err))
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.keys = keys
+		c.mu.Unlock()
+
+		return nil, nil
+	})
+}
+
+func (c *JWKSCache) fetch() (map[string]crypto.PublicKey, error) {
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var set JWKSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.PublicKey()
+		if err != nil {
+			zap.L().Warn("Skipping unsupported JWK", zap.String("kid", jwk.KeyID), zap.Error(err))
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+
+	return keys, nil
+}
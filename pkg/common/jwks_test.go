@@ -0,0 +1,54 @@
+package common_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/pkg/common"
+)
+
+func TestJWKSCacheSharesFetches(t *testing.T) {
+	signingKey, err := common.GenerateSigningKey()
+	assert.NoError(t, err)
+
+	set, err := common.NewKeyring(signingKey).PublicKeySet()
+	assert.NoError(t, err)
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	defer server.Close()
+
+	keys := common.NewJWKSCache(server.URL, time.Hour)
+	token := &jwt.Token{Header: map[string]interface{}{"kid": signingKey.ID}}
+
+	// Requests arriving during a fetch wait for it instead of fetching again
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, err := keys.Keyfunc(token)
+			assert.NoError(t, err)
+			assert.Equal(t, signingKey.Private.Public(), key)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// Unknown kids don't fetch again right away
+	_, err = keys.Keyfunc(&jwt.Token{Header: map[string]interface{}{"kid": "unknown"}})
+	assert.ErrorIs(t, err, common.ErrUnknownKey)
+	assert.Equal(t, int32(1), fetches.Load())
+}
@@ -1,22 +1,53 @@
 package common
 
 import (
+	"context"
+
 	"github.com/segmentio/kafka-go"
 )
 
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+type KafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
 func NewKafkaWriter(env *Environment) *kafka.Writer {
+	return newKafkaWriter(env, env.KafkaTopic)
+}
+
+func NewKafkaReader(env *Environment) *kafka.Reader {
+	return newKafkaReader(env, env.KafkaTopic, env.KafkaGroupID)
+}
+
+func NewKafkaDeadLetterWriter(env *Environment) *kafka.Writer {
+	return newKafkaWriter(env, env.KafkaDLQTopic)
+}
+
+// NewKafkaDeadLetterReader uses its own consumer group, so replaying doesn't
+// interfere with the offsets of the notification service.
+func NewKafkaDeadLetterReader(env *Environment) *kafka.Reader {
+	return newKafkaReader(env, env.KafkaDLQTopic, env.KafkaGroupID+"-replay")
+}
+
+func newKafkaWriter(env *Environment, topic string) *kafka.Writer {
 	return kafka.NewWriter(kafka.WriterConfig{
 		Brokers:  []string{env.KafkaBrokers},
-		Topic:    env.KafkaTopic,
-		Balancer: &kafka.LeastBytes{},
+		Topic:    topic,
+		Balancer: &kafka.Hash{},
 	})
 }
 
-func NewKafkaReader(env *Environment) *kafka.Reader {
+func newKafkaReader(env *Environment, topic, groupID string) *kafka.Reader {
 	return kafka.NewReader(kafka.ReaderConfig{
 		Brokers:  []string{env.KafkaBrokers},
-		Topic:    env.KafkaTopic,
-		GroupID:  env.KafkaGroupID,
+		Topic:    topic,
+		GroupID:  groupID,
 		MinBytes: 10e3,
 		MaxBytes: 10e6,
 	})
@@ -0,0 +1,254 @@
+package common
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Signing keys are rotated without logging anyone out. A new key is published
+// in the key set before it starts signing, so verifiers already have it.
+// Replaced keys keep verifying until the tokens they signed have expired.
+
+// keyringRefreshInterval is how often the auth service reloads the keyring,
+// it must be shorter than the publish delay of new keys.
+const keyringRefreshInterval = time.Minute
+
+type Keyring struct {
+	mu   sync.RWMutex
+	keys []*SigningKey
+}
+
+var keyring *Keyring
+
+func NewKeyring(keys ...*SigningKey) *Keyring {
+	k := &Keyring{}
+	k.set(keys)
+	return k
+}
+
+func SetKeyring(k *Keyring) {
+	keyring = k
+}
+
+func (k *Keyring) set(keys []*SigningKey) {
+	// Newest first
+	sorted := append([]*SigningKey(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActivatesAt.After(sorted[j].ActivatesAt)
+	})
+
+	k.mu.Lock()
+	k.keys = sorted
+	k.mu.Unlock()
+}
+
+// Current returns the newest active key, the one tokens are signed with.
+func (k *Keyring) Current() (*SigningKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range k.keys {
+		if !key.ActivatesAt.After(now) {
+			return key, nil
+		}
+	}
+
+	return nil, ErrNoSigningKey
+}
+
+func (k *Keyring) Key(kid string) (*SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+func (k *Keyring) PublicKeySet() (JWKSet, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(k.keys))}
+	for _, key := range k.keys {
+		jwk, err := NewJWK(key.Private.Public())
+		if err != nil {
+			return JWKSet{}, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set, nil
+}
+
+// PublicKeySet is the key set published by the auth service.
+func PublicKeySet() (JWKSet, error) {
+	if keyring == nil {
+		return JWKSet{}, ErrNoSigningKey
+	}
+
+	return keyring.PublicKeySet()
+}
+
+func currentSigningKey() (*SigningKey, error) {
+	if keyring == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	return keyring.Current()
+}
+
+func signingKeyFunc(token *jwt.Token) (interface{}, error) {
+	if keyring == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := keyring.Key(kid)
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key.Private.Public(), nil
+}
+
+// InitKeyring loads the keyring of the auth service. An empty keyring is
+// seeded with the key file in JWT_PRIVATE_KEY, or a generated key.
+func InitKeyring(env *Environment) {
+	keys, err := LoadSigningKeys(DB)
+	if err != nil {
+		zap.L().Fatal("Failed to load signing keys", zap.Error(err))
+	}
+
+	if len(keys) == 0 {
+		var key *SigningKey
+		if env.JwtPrivateKey == "" {
+			key, err = GenerateSigningKey()
+		} else {
+			key, err = LoadSigningKey(env.JwtPrivateKey)
+		}
+		if err == nil {
+			key.ActivatesAt = time.Now()
+			err = SaveSigningKey(DB, key)
+		}
+		if err != nil {
+			zap.L().Fatal("Failed to create signing key", zap.Error(err))
+		}
+
+		zap.L().Info("Created the first signing key", zap.String("kid", key.ID))
+		keys = []*SigningKey{key}
+	}
+
+	SetKeyring(NewKeyring(keys...))
+}
+
+// RunKeyringRefresh reloads the keyring, so rotations made by the rotate-keys
+// command are picked up.
+func RunKeyringRefresh(ctx context.Context) {
+	ticker := time.NewTicker(keyringRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			keys, err := LoadSigningKeys(DB)
+			if err != nil {
+				zap.L().Error("Failed to reload signing keys", zap.Error(err))
+				continue
+			}
+			keyring.set(keys)
+		}
+	}
+}
+
+// LoadSigningKeys returns the keys that are not expired yet.
+func LoadSigningKeys(db *gorm.DB) ([]*SigningKey, error) {
+	var rows []entities.SigningKey
+	err := db.Where("expires_at IS NULL OR expires_at > ?", time.Now()).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*SigningKey, 0, len(rows))
+	for _, row := range rows {
+		key, err := ParseSigningKey([]byte(row.PrivateKey))
+		if err != nil {
+			return nil, err
+		}
+		key.ActivatesAt = row.ActivatesAt
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func SaveSigningKey(db *gorm.DB, key *SigningKey) error {
+	privateKey, err := key.EncodePrivateKey()
+	if err != nil {
+		return err
+	}
+
+	return db.Create(&entities.SigningKey{
+		KeyID:       key.ID,
+		Algorithm:   key.Algorithm,
+		PrivateKey:  privateKey,
+		ActivatesAt: key.ActivatesAt,
+	}).Error
+}
+
+// RotateSigningKeys adds a key that starts signing after publishDelay. The
+// keys it replaces expire maxTokenTTL after that.
+func RotateSigningKeys(db *gorm.DB, publishDelay, maxTokenTTL time.Duration) (*SigningKey, error) {
+	key, err := GenerateSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	key.ActivatesAt = time.Now().Add(publishDelay)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entities.SigningKey{}).
+			Where("expires_at IS NULL").
+			Update("expires_at", key.ActivatesAt.Add(maxTokenTTL)).Error
+		if err != nil {
+			return err
+		}
+
+		return SaveSigningKey(tx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// RetireSigningKeys deletes the expired keys and returns how many there were.
+func RetireSigningKeys(db *gorm.DB) (int64, error) {
+	result := db.Where("expires_at <= ?", time.Now()).Delete(&entities.SigningKey{})
+	return result.RowsAffected, result.Error
+}
+
+// NewestSigningKey returns the most recently added key, nil if there is none.
+func NewestSigningKey(db *gorm.DB) (*entities.SigningKey, error) {
+	var key entities.SigningKey
+	err := db.Order("activates_at desc").Limit(1).Find(&key).Error
+	if err != nil || key.KeyID == "" {
+		return nil, err
+	}
+
+	return &key, nil
+}
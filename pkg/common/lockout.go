@@ -0,0 +1,210 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Failed logins are counted in Redis per account and per client IP. Every
+// failure of an account delays the next answer a bit more, and too many
+// failures within the window lock the account or the IP for a while.
+
+var ErrInvalidUnlockToken = errors.New("unlock token is invalid or expired")
+
+type LoginLimits struct {
+	AccountFailures int64
+	IPFailures      int64
+	Window          time.Duration
+	Lockout         time.Duration
+	Delay           time.Duration
+	MaxDelay        time.Duration
+}
+
+func NewLoginLimits(env *Environment) LoginLimits {
+	return LoginLimits{
+		AccountFailures: ParseInt("LOGIN_MAX_FAILURES", env.LoginMaxFailures),
+		IPFailures:      ParseInt("LOGIN_IP_MAX_FAILURES", env.LoginIPFailures),
+		Window:          ParseDuration("LOGIN_FAILURE_WINDOW", env.LoginWindow),
+		Lockout:         ParseDuration("LOGIN_LOCKOUT_DURATION", env.LoginLockout),
+		Delay:           ParseDuration("LOGIN_DELAY", env.LoginDelay),
+		MaxDelay:        ParseDuration("LOGIN_MAX_DELAY", env.LoginMaxDelay),
+	}
+}
+
+// LoginFailure is the state of an account after a failed login.
+type LoginFailure struct {
+	Failures int64
+	// Locked is only set by the failure that locked the account
+	Locked      bool
+	LockedUntil time.Time
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginFailuresKey(kind, id string) string {
+	return fmt.Sprintf("login_failures:%s:%s", kind, id)
+}
+
+func loginLockKey(kind, id string) string {
+	return fmt.Sprintf("login_lock:%s:%s", kind, id)
+}
+
+func unlockTokenKey(token string) string {
+	return fmt.Sprintf("login_unlock:%s", HashToken(token))
+}
+
+// LoginLockedUntil returns until when logins of the account or from the IP
+// are locked, or the zero time.
+func LoginLockedUntil(ctx context.Context, email, ip string) (time.Time, error) {
+	email = normalizeEmail(email)
+
+	values, err := RedisClient.MGet(ctx, loginLockKey("account", email), loginLockKey("ip", ip)).Result()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var lockedUntil time.Time
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		unix, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if until := time.Unix(unix, 0); until.After(lockedUntil) {
+			lockedUntil = until
+		}
+	}
+
+	if !lockedUntil.After(time.Now()) {
+		return time.Time{}, nil
+	}
+
+	return lockedUntil, nil
+}
+
+// RecordLoginFailure counts a failed login of the account from the IP.
+func RecordLoginFailure(ctx context.Context, limits LoginLimits, email, ip string) (LoginFailure, error) {
+	email = normalizeEmail(email)
+
+	ipFailures, err := incrLoginFailures(ctx, loginFailuresKey("ip", ip), limits.Window)
+	if err != nil {
+		return LoginFailure{}, err
+	}
+
+	if ipFailures >= limits.IPFailures {
+		if _, err := lockLogin(ctx, "ip", ip, limits.Lockout); err != nil {
+			return LoginFailure{}, err
+		}
+	}
+
+	failures, err := incrLoginFailures(ctx, loginFailuresKey("account", email), limits.Window)
+	if err != nil {
+		return LoginFailure{}, err
+	}
+
+	failure := LoginFailure{Failures: failures}
+	if failures >= limits.AccountFailures {
+		failure.Locked = true
+		failure.LockedUntil, err = lockLogin(ctx, "account", email, limits.Lockout)
+		if err != nil {
+			return LoginFailure{}, err
+		}
+	}
+
+	return failure, nil
+}
+
+func incrLoginFailures(ctx context.Context, key string, window time.Duration) (int64, error) {
+	failures, err := RedisClient.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// The window starts with the first failure
+	if failures == 1 {
+		if err := RedisClient.Expire(ctx, key, window).Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	return failures, nil
+}
+
+// lockLogin locks the account or IP and starts counting failures anew, so the
+// next lockout needs as many failures again.
+func lockLogin(ctx context.Context, kind, id string, lockout time.Duration) (time.Time, error) {
+	lockedUntil := time.Now().Add(lockout)
+
+	err := RedisClient.Set(ctx, loginLockKey(kind, id), lockedUntil.Unix(), lockout).Err()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := RedisClient.Del(ctx, loginFailuresKey(kind, id)).Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	return lockedUntil, nil
+}
+
+// LoginDelay doubles with every failure, up to the maximum delay.
+func LoginDelay(limits LoginLimits, failures int64) time.Duration {
+	if failures < 1 || limits.Delay <= 0 {
+		return 0
+	}
+
+	delay := limits.Delay
+	for i := int64(1); i < failures && delay < limits.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, limits.MaxDelay)
+}
+
+// ClearLoginFailures unlocks the account and forgets its failed logins.
+func ClearLoginFailures(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	return RedisClient.Del(ctx, loginFailuresKey("account", email), loginLockKey("account", email)).Err()
+}
+
+// CreateUnlockToken creates the token of the unlock link sent on lockout.
+func CreateUnlockToken(ctx context.Context, email string, ttl time.Duration) (string, error) {
+	token, err := GenerateSecureToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = RedisClient.Set(ctx, unlockTokenKey(token), normalizeEmail(email), ttl).Err()
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// UnlockAccount unlocks the account of an unlock token. A token can only be
+// used once.
+func UnlockAccount(ctx context.Context, token string) (string, error) {
+	email, err := RedisClient.GetDel(ctx, unlockTokenKey(token)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrInvalidUnlockToken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return email, ClearLoginFailures(ctx, email)
+}
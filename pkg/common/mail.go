@@ -3,52 +3,153 @@ package common
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"time"
 
-	"github.com/mailjet/mailjet-apiv3-go"
-	"github.com/segmentio/kafka-go"
+	"github.com/whitehead421/todo-backend/pkg/models"
 	"go.uber.org/zap"
 )
 
-func SendActivationMail(reader *kafka.Reader, ctx context.Context) {
-	for {
-		msg, err := reader.ReadMessage(ctx)
-		if err != nil {
-			zap.L().Error("Failed to read message from Kafka", zap.Error(err))
-			continue
-		}
+// sendTemplateMail renders the template in the recipient's locale and sends
+// it. Nothing is sent once ctx is cancelled, as the event is delivered again.
+func sendTemplateMail(ctx context.Context, event models.Event, template, to, locale string, data interface{}) error {
+	mail, err := RenderMail(to, locale, template, data)
+	if err != nil {
+		return err
+	}
 
-		err = sendEmail(string(msg.Key), string(msg.Value))
-		if err != nil {
-			zap.L().Error("Failed to send activation email", zap.Error(err))
-			continue
-		}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-		zap.L().Info(
-			"Sent activation email",
-			zap.String("email", string(msg.Key)),
-		)
+	err = MailClient.Send(mail)
+	if err != nil {
+		return err
 	}
+
+	zap.L().Info(
+		"Sent email",
+		zap.String("template", template),
+		zap.String("event ID", event.ID),
+		zap.String("email", to),
+	)
+
+	return nil
 }
 
-func sendEmail(toEmail, token string) error {
-	env := GetEnvironmentVariables()
-	mailjetClient := mailjet.NewMailjetClient(env.MailjetAPIKey, env.MailjetSecretKey)
+func SendActivationMail(ctx context.Context, event models.Event) error {
+	var payload models.UserRegisteredPayload
+	if err := DecodePayload(event, &payload); err != nil {
+		return err
+	}
 
-	email := &mailjet.InfoSendMail{
-		FromEmail: env.SenderEmail,
-		FromName:  "Todo App",
-		Subject:   "Verify Your Account",
-		TextPart:  fmt.Sprintf("Please use the following token to activate your account: %s", token),
-		HTMLPart:  fmt.Sprintf("<h3>Please use the following link to activate your account:</h3><a target='_blank' href='http://%s:%s/verify?token=%s'>Activate</a>", env.ApplicationHost, env.AuthPort, token),
-		Recipients: []mailjet.Recipient{
-			{Email: toEmail},
-		},
+	return sendTemplateMail(ctx, event, "activation", payload.Email, payload.Locale, struct {
+		Name string
+		Link string
+	}{
+		Name: payload.Name,
+		Link: fmt.Sprintf("%s/verify?token=%s", authBaseURL, url.QueryEscape(payload.VerifyToken)),
+	})
+}
+
+func SendPasswordResetMail(ctx context.Context, event models.Event) error {
+	var payload models.PasswordResetRequestedPayload
+	if err := DecodePayload(event, &payload); err != nil {
+		return err
 	}
 
-	_, err := mailjetClient.SendMail(email)
-	if err != nil {
+	return sendTemplateMail(ctx, event, "password_reset", payload.Email, payload.Locale, struct {
+		Name      string
+		Link      string
+		ExpiresAt time.Time
+	}{
+		Name:      payload.Name,
+		Link:      fmt.Sprintf("%s/reset-password?token=%s", frontendBaseURL, url.QueryEscape(payload.Token)),
+		ExpiresAt: payload.ExpiresAt,
+	})
+}
+
+func SendAccountLockedMail(ctx context.Context, event models.Event) error {
+	var payload models.AccountLockedPayload
+	if err := DecodePayload(event, &payload); err != nil {
 		return err
 	}
 
-	return nil
+	return sendTemplateMail(ctx, event, "account_locked", payload.Email, payload.Locale, struct {
+		Name        string
+		Link        string
+		LockedUntil time.Time
+	}{
+		Name:        payload.Name,
+		Link:        fmt.Sprintf("%s/unlock?token=%s", authBaseURL, url.QueryEscape(payload.UnlockToken)),
+		LockedUntil: payload.LockedUntil,
+	})
+}
+
+func SendEmailChangeNoticeMail(ctx context.Context, event models.Event) error {
+	var payload models.EmailChangeRequestedPayload
+	if err := DecodePayload(event, &payload); err != nil {
+		return err
+	}
+
+	return sendTemplateMail(ctx, event, "email_change_notice", payload.Email, payload.Locale, struct {
+		Name     string
+		NewEmail string
+	}{
+		Name:     payload.Name,
+		NewEmail: payload.NewEmail,
+	})
+}
+
+func SendEmailConfirmationMail(ctx context.Context, event models.Event) error {
+	var payload models.EmailChangeConfirmingPayload
+	if err := DecodePayload(event, &payload); err != nil {
+		return err
+	}
+
+	return sendTemplateMail(ctx, event, "email_confirmation", payload.Email, payload.Locale, struct {
+		Name      string
+		Link      string
+		ExpiresAt time.Time
+	}{
+		Name:      payload.Name,
+		Link:      fmt.Sprintf("%s/email/confirm?token=%s", authBaseURL, url.QueryEscape(payload.Token)),
+		ExpiresAt: payload.ExpiresAt,
+	})
+}
+
+func SendInvitationMail(ctx context.Context, event models.Event) error {
+	var payload models.MemberInvitedPayload
+	if err := DecodePayload(event, &payload); err != nil {
+		return err
+	}
+
+	return sendTemplateMail(ctx, event, "invitation", payload.Email, payload.Locale, struct {
+		InviterName      string
+		OrganizationName string
+		Role             string
+		Link             string
+		ExpiresAt        time.Time
+	}{
+		InviterName:      payload.InviterName,
+		OrganizationName: payload.OrganizationName,
+		Role:             payload.Role,
+		Link:             fmt.Sprintf("%s/invitations/accept?token=%s", frontendBaseURL, url.QueryEscape(payload.Token)),
+		ExpiresAt:        payload.ExpiresAt,
+	})
+}
+
+func SendReminderMail(ctx context.Context, event models.Event) error {
+	var payload models.TodoReminderDuePayload
+	if err := DecodePayload(event, &payload); err != nil {
+		return err
+	}
+
+	return sendTemplateMail(ctx, event, "reminder", payload.Email, payload.Locale, struct {
+		Description string
+		DueAt       *time.Time
+	}{
+		Description: payload.Description,
+		DueAt:       payload.DueAt,
+	})
 }
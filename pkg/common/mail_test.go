@@ -0,0 +1,124 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/models"
+)
+
+type fakeMailer struct {
+	sent []common.Mail
+}
+
+func (m *fakeMailer) Send(mail common.Mail) error {
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+func TestSendTemplateMail(t *testing.T) {
+	dueAt := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		eventType       models.EventType
+		payload         interface{}
+		send            func(ctx context.Context, event models.Event) error
+		expectedTo      string
+		expectedContent string
+	}{
+		{
+			name:      "Activation",
+			eventType: models.UserRegistered,
+			payload: models.UserRegisteredPayload{
+				Email:       "user@example.com",
+				Name:        "User",
+				Locale:      "en",
+				VerifyToken: "verify-token",
+			},
+			send:            common.SendActivationMail,
+			expectedTo:      "user@example.com",
+			expectedContent: "verify?token=verify-token",
+		},
+		{
+			name:      "Email Change Notice",
+			eventType: models.EmailChangeRequested,
+			payload: models.EmailChangeRequestedPayload{
+				Email:    "old@example.com",
+				Name:     "User",
+				Locale:   "en",
+				NewEmail: "new@example.com",
+			},
+			send:            common.SendEmailChangeNoticeMail,
+			expectedTo:      "old@example.com",
+			expectedContent: "new@example.com",
+		},
+		{
+			name:      "Reminder",
+			eventType: models.TodoReminderDue,
+			payload: models.TodoReminderDuePayload{
+				TodoID:      1,
+				Email:       "user@example.com",
+				Locale:      "en",
+				Description: "Water the plants",
+				DueAt:       &dueAt,
+			},
+			send:            common.SendReminderMail,
+			expectedTo:      "user@example.com",
+			expectedContent: "Water the plants",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mailer := &fakeMailer{}
+			common.SetMailer(mailer)
+
+			event, err := common.NewEvent(test.eventType, test.payload)
+			assert.NoError(t, err)
+
+			err = test.send(context.Background(), event)
+			assert.NoError(t, err)
+
+			if assert.Len(t, mailer.sent, 1) {
+				assert.Equal(t, test.expectedTo, mailer.sent[0].To)
+				assert.NotEmpty(t, mailer.sent[0].Subject)
+				assert.Contains(t, mailer.sent[0].TextPart, test.expectedContent)
+				assert.Contains(t, mailer.sent[0].HTMLPart, test.expectedContent)
+			}
+		})
+	}
+}
+
+func TestSendTemplateMailAfterCancel(t *testing.T) {
+	mailer := &fakeMailer{}
+	common.SetMailer(mailer)
+
+	event, err := common.NewEvent(models.UserRegistered, models.UserRegisteredPayload{
+		Email:  "user@example.com",
+		Locale: "en",
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = common.SendActivationMail(ctx, event)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, mailer.sent)
+}
+
+func TestSendTemplateMailWithUndecodablePayload(t *testing.T) {
+	mailer := &fakeMailer{}
+	common.SetMailer(mailer)
+
+	event, err := common.NewEvent(models.UserRegistered, "not a payload")
+	assert.NoError(t, err)
+
+	err = common.SendActivationMail(context.Background(), event)
+	assert.True(t, common.IsPermanent(err))
+	assert.Empty(t, mailer.sent)
+}
@@ -0,0 +1,191 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mailjet/mailjet-apiv3-go"
+	"go.uber.org/zap"
+)
+
+const senderName = "Todo App"
+
+type Mail struct {
+	To       string
+	Subject  string
+	TextPart string
+	HTMLPart string
+}
+
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+var MailClient Mailer
+
+// Public addresses of the auth service and the frontend, used for links in mails.
+var (
+	authBaseURL     string
+	frontendBaseURL string
+)
+
+// InitMailer picks the mail backend configured by MAIL_BACKEND.
+func InitMailer(env *Environment) {
+	mailer, err := NewMailer(env)
+	if err != nil {
+		zap.L().Fatal("Failed to initialize mailer", zap.Error(err))
+	}
+
+	zap.L().Info("Initialized mailer", zap.String("backend", env.MailBackend))
+
+	MailClient = mailer
+	authBaseURL = env.AuthBaseURL
+	frontendBaseURL = env.FrontendBaseURL
+}
+
+func SetMailer(mailer Mailer) {
+	MailClient = mailer
+}
+
+func NewMailer(env *Environment) (Mailer, error) {
+	switch env.MailBackend {
+	case "mailjet":
+		if env.MailjetAPIKey == "" || env.MailjetSecretKey == "" {
+			return nil, errors.New("MAIL_API_KEY and MAIL_SECRET are required for the mailjet backend")
+		}
+		return &mailjetMailer{
+			client: mailjet.NewMailjetClient(env.MailjetAPIKey, env.MailjetSecretKey),
+			from:   env.SenderEmail,
+		}, nil
+	case "smtp":
+		return &smtpMailer{
+			addr:     env.SmtpAddr,
+			username: env.SmtpUsername,
+			password: env.SmtpPassword,
+			from:     env.SenderEmail,
+		}, nil
+	case "file":
+		if err := os.MkdirAll(env.MailDir, 0o755); err != nil {
+			return nil, err
+		}
+		return &fileMailer{
+			dir:  env.MailDir,
+			from: env.SenderEmail,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend: %s", env.MailBackend)
+	}
+}
+
+type mailjetMailer struct {
+	client *mailjet.Client
+	from   string
+}
+
+func (m *mailjetMailer) Send(mail Mail) error {
+	_, err := m.client.SendMail(&mailjet.InfoSendMail{
+		FromEmail: m.from,
+		FromName:  senderName,
+		Subject:   mail.Subject,
+		TextPart:  mail.TextPart,
+		HTMLPart:  mail.HTMLPart,
+		Recipients: []mailjet.Recipient{
+			{Email: mail.To},
+		},
+	})
+
+	return err
+}
+
+// smtpMailer sends plain SMTP, which also works against a local MailHog.
+type smtpMailer struct {
+	addr     string
+	username string
+	password string
+	from     string
+}
+
+func (m *smtpMailer) Send(mail Mail) error {
+	message, err := buildMessage(m.from, mail)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		host, _, _ := net.SplitHostPort(m.addr)
+		auth = smtp.PlainAuth("", m.username, m.password, host)
+	}
+
+	return smtp.SendMail(m.addr, auth, m.from, []string{mail.To}, message)
+}
+
+// fileMailer writes every mail as an .eml file, for local development and tests.
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+func (m *fileMailer) Send(mail Mail) error {
+	message, err := buildMessage(m.from, mail)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), GenerateUUID())
+
+	return os.WriteFile(filepath.Join(m.dir, name), message, 0o644)
+}
+
+// buildMessage renders a multipart/alternative MIME message with both parts.
+func buildMessage(from string, mail Mail) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", mail.TextPart},
+		{"text/html; charset=UTF-8", mail.HTMLPart},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", mime.QEncoding.Encode("UTF-8", senderName)+" <"+from+">")
+	fmt.Fprintf(&message, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", mail.Subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
+}
@@ -0,0 +1,113 @@
+package common_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/pkg/common"
+)
+
+func TestNewMailer(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          common.Environment
+		expectedType common.Mailer
+		expectError  bool
+	}{
+		{
+			name: "Mailjet",
+			env: common.Environment{
+				MailBackend:      "mailjet",
+				MailjetAPIKey:    "key",
+				MailjetSecretKey: "secret",
+				SenderEmail:      "noreply@example.com",
+			},
+			expectedType: &common.MailjetMailer{},
+		},
+		{
+			name: "Mailjet Without Credentials",
+			env: common.Environment{
+				MailBackend: "mailjet",
+				SenderEmail: "noreply@example.com",
+			},
+			expectError: true,
+		},
+		{
+			name: "SMTP",
+			env: common.Environment{
+				MailBackend: "smtp",
+				SmtpAddr:    "localhost:1025",
+				SenderEmail: "noreply@example.com",
+			},
+			expectedType: &common.SMTPMailer{},
+		},
+		{
+			name: "File",
+			env: common.Environment{
+				MailBackend: "file",
+				MailDir:     filepath.Join(t.TempDir(), "mails"),
+				SenderEmail: "noreply@example.com",
+			},
+			expectedType: &common.FileMailer{},
+		},
+		{
+			name: "Unknown Backend",
+			env: common.Environment{
+				MailBackend: "carrier-pigeon",
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mailer, err := common.NewMailer(&test.env)
+			if test.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, mailer)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.IsType(t, test.expectedType, mailer)
+		})
+	}
+}
+
+func TestFileMailerWritesMail(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mails")
+
+	mailer, err := common.NewMailer(&common.Environment{
+		MailBackend: "file",
+		MailDir:     dir,
+		SenderEmail: "noreply@example.com",
+	})
+	assert.NoError(t, err)
+
+	err = mailer.Send(common.Mail{
+		To:       "user@example.com",
+		Subject:  "Welcome",
+		TextPart: "Hello in text",
+		HTMLPart: "<p>Hello in HTML</p>",
+	})
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.NoError(t, err)
+	if !assert.Len(t, files, 1) {
+		return
+	}
+
+	content, err := os.ReadFile(files[0])
+	assert.NoError(t, err)
+
+	message := string(content)
+	assert.Contains(t, message, "To: user@example.com\r\n")
+	assert.Contains(t, message, "Subject: Welcome\r\n")
+	assert.Contains(t, message, "<noreply@example.com>")
+	assert.Contains(t, message, "Content-Type: multipart/alternative")
+	assert.True(t, strings.Index(message, "Hello in text") < strings.Index(message, "<p>Hello in HTML</p>"))
+}
@@ -0,0 +1,69 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// A login of a user with two-factor authentication first creates a challenge.
+// The challenge token is exchanged for a session together with a code.
+
+const mfaChallengeMaxAttempts = 5
+
+var ErrInvalidMFAChallenge = errors.New("mfa challenge is invalid or expired")
+
+func mfaChallengeKey(token string) string {
+	return fmt.Sprintf("mfa_challenge:%s", HashToken(token))
+}
+
+func CreateMFAChallenge(ctx context.Context, userID uint64, ttl time.Duration) (string, error) {
+	token, err := GenerateSecureToken()
+	if err != nil {
+		return "", err
+	}
+
+	key := mfaChallengeKey(token)
+	if err := RedisClient.HSet(ctx, key, "user_id", userID).Err(); err != nil {
+		return "", err
+	}
+
+	if err := RedisClient.Expire(ctx, key, ttl).Err(); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func MFAChallengeUser(ctx context.Context, token string) (uint64, error) {
+	userID, err := RedisClient.HGet(ctx, mfaChallengeKey(token), "user_id").Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, ErrInvalidMFAChallenge
+	}
+
+	return userID, err
+}
+
+// FailMFAChallenge counts a wrong code. Too many wrong codes end the
+// challenge, so codes can't be guessed.
+func FailMFAChallenge(ctx context.Context, token string) error {
+	key := mfaChallengeKey(token)
+
+	attempts, err := RedisClient.HIncrBy(ctx, key, "attempts", 1).Result()
+	if err != nil {
+		return err
+	}
+
+	if attempts >= mfaChallengeMaxAttempts {
+		return RedisClient.Del(ctx, key).Err()
+	}
+
+	return nil
+}
+
+func DeleteMFAChallenge(ctx context.Context, token string) error {
+	return RedisClient.Del(ctx, mfaChallengeKey(token)).Err()
+}
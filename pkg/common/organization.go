@@ -0,0 +1,41 @@
+package common
+
+import (
+	"errors"
+
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// OrganizationRole returns the role of the user in the organization, or the
+// empty role if the user isn't a member.
+func OrganizationRole(db *gorm.DB, organizationID, userID uint64) (models.Role, error) {
+	var member entities.OrganizationMember
+	result := db.Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&member)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if result.Error != nil {
+		return "", result.Error
+	}
+
+	return models.Role(member.Role), nil
+}
+
+// SoleOwnedOrganizations returns the organizations the user is the only owner
+// of, which would be left without an owner if the user left.
+func SoleOwnedOrganizations(db *gorm.DB, userID uint64) ([]uint64, error) {
+	owners := db.Model(&entities.OrganizationMember{}).
+		Select("organization_id").
+		Where("role = ?", models.RoleOwner).
+		Group("organization_id").
+		Having("COUNT(*) = 1")
+
+	var organizationIDs []uint64
+	err := db.Model(&entities.OrganizationMember{}).
+		Where("user_id = ? AND role = ? AND organization_id IN (?)", userID, models.RoleOwner, owners).
+		Pluck("organization_id", &organizationIDs).Error
+
+	return organizationIDs, err
+}
@@ -0,0 +1,128 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	outboxBatchSize  = 100
+	outboxMaxBackoff = 5 * time.Minute
+)
+
+// EnqueueEvent stores an event in the outbox using the given transaction, so
+// the event is only published if the surrounding change is committed.
+func EnqueueEvent(tx *gorm.DB, eventType models.EventType, key string, payload interface{}) error {
+	event, err := NewEvent(eventType, payload)
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&entities.OutboxEvent{
+		EventID:    event.ID,
+		EventType:  string(event.Type),
+		MessageKey: key,
+		Value:      string(value),
+	}).Error
+}
+
+// RunOutboxRelay publishes outbox events until ctx is cancelled. Failed
+// batches are retried with exponential backoff and events are never skipped,
+// which gives at-least-once delivery; consumers dedupe by event ID.
+func RunOutboxRelay(ctx context.Context, writer KafkaWriter, interval time.Duration) {
+	wait := interval
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		sent, err := RelayOutbox(ctx, writer)
+		if err != nil {
+			wait = min(wait*2, outboxMaxBackoff)
+			zap.L().Error("Failed to relay outbox events",
+				zap.Duration("retry in", wait),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		wait = interval
+		if sent > 0 {
+			zap.L().Info("Relayed outbox events", zap.Int("count", sent))
+		}
+	}
+}
+
+// RelayOutbox publishes the oldest batch of unsent events. The rows stay
+// locked while they are written to Kafka, so relays in several replicas don't
+// publish the same batch twice.
+func RelayOutbox(ctx context.Context, writer KafkaWriter) (int, error) {
+	var events []entities.OutboxEvent
+	var writeErr error
+
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NULL").
+			Order("id").
+			Limit(outboxBatchSize).
+			Find(&events)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]uint64, 0, len(events))
+		messages := make([]kafka.Message, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+			messages = append(messages, NewEventMessage(
+				models.EventType(event.EventType),
+				event.MessageKey,
+				[]byte(event.Value),
+			))
+		}
+
+		writeErr = writer.WriteMessages(ctx, messages...)
+		if writeErr != nil {
+			// Keep the failure on the rows and commit, the batch is retried later
+			return tx.Model(&entities.OutboxEvent{}).
+				Where("id IN ?", ids).
+				Updates(map[string]interface{}{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": writeErr.Error(),
+				}).Error
+		}
+
+		return tx.Model(&entities.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("sent_at", time.Now()).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	if writeErr != nil {
+		return 0, writeErr
+	}
+
+	return len(events), nil
+}
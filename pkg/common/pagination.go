@@ -0,0 +1,39 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Cursor points at the last item of a page. Sort is kept in the cursor so a
+// cursor issued for one ordering can't be replayed against another. Value is
+// nil when the sort column of the last item is NULL.
+type Cursor struct {
+	Sort  string     `json:"s"`
+	Value *time.Time `json:"v"`
+	ID    uint64     `json:"id"`
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+func EncodeCursor(cursor Cursor) string {
+	bytes, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+func DecodeCursor(encoded string) (Cursor, error) {
+	var cursor Cursor
+
+	bytes, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(bytes, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
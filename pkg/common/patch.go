@@ -0,0 +1,318 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPatch    = errors.New("invalid patch")
+	ErrPatchTestFailed = errors.New("patch test failed")
+)
+
+// JSONPatchOperation is an operation of an RFC 6902 JSON patch.
+type JSONPatchOperation struct {
+	Op    string           `json:"op"`
+	Path  string           `json:"path"`
+	From  string           `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// MergePatch applies an RFC 7396 JSON merge patch to a JSON document. A null
+// in the patch removes the member from the document.
+func MergePatch(document, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(mergeValue(target, changes))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	members, ok := target.(map[string]interface{})
+	if !ok {
+		members = map[string]interface{}{}
+	}
+
+	for key, value := range changes {
+		if value == nil {
+			delete(members, key)
+			continue
+		}
+		members[key] = mergeValue(members[key], value)
+	}
+
+	return members
+}
+
+// JSONPatch applies an RFC 6902 JSON patch to a JSON document. The operations
+// are applied in order and the patch fails as a whole if any of them does.
+func JSONPatch(document, patch []byte) ([]byte, error) {
+	var target interface{}
+	var operations []JSONPatchOperation
+
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+	}
+
+	for i, operation := range operations {
+		var err error
+		target, err = applyOperation(target, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func applyOperation(target interface{}, operation JSONPatchOperation) (interface{}, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		if operation.Value == nil {
+			return nil, fmt.Errorf("%w: %s requires a value", ErrInvalidPatch, operation.Op)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(*operation.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+		}
+
+		switch operation.Op {
+		case "add":
+			return addValue(target, path, value)
+		case "replace":
+			if len(path) == 0 {
+				return value, nil
+			}
+			if target, _, err = removeValue(target, path); err != nil {
+				return nil, err
+			}
+			return addValue(target, path, value)
+		default:
+			current, err := getValue(target, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("%w: %s", ErrPatchTestFailed, operation.Path)
+			}
+			return target, nil
+		}
+	case "remove":
+		target, _, err = removeValue(target, path)
+		return target, err
+	case "move", "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		if operation.Op == "move" {
+			if len(from) < len(path) && reflect.DeepEqual(from, path[:len(from)]) {
+				return nil, fmt.Errorf("%w: can't move %s into itself", ErrInvalidPatch, operation.From)
+			}
+			if target, value, err = removeValue(target, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = getValue(target, from); err != nil {
+				return nil, err
+			}
+			// Copies must not share maps or slices with the original
+			encoded, _ := json.Marshal(value)
+			_ = json.Unmarshal(encoded, &value)
+		}
+
+		return addValue(target, path, value)
+	}
+
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, operation.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: invalid path %q", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// arrayIndex parses an array index, allowing the one past the end if asked to.
+func arrayIndex(token string, length int, end bool) (int, error) {
+	if end && token == "-" {
+		return length, nil
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > length || (index == length && !end) || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+
+	return index, nil
+}
+
+func getValue(target interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := target.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q doesn't exist", ErrInvalidPatch, token)
+			}
+			target = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			target = node[index]
+		default:
+			return nil, fmt.Errorf("%w: %q doesn't exist", ErrInvalidPatch, token)
+		}
+	}
+
+	return target, nil
+}
+
+func addValue(target interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token := path[0]
+
+	switch node := target.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			node[token] = value
+			return node, nil
+		}
+
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q doesn't exist", ErrInvalidPatch, token)
+		}
+
+		child, err := addValue(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+
+		return node, nil
+	case []interface{}:
+		if len(path) == 1 {
+			index, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+
+			return node, nil
+		}
+
+		index, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+
+		child, err := addValue(node[index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = child
+
+		return node, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q doesn't exist", ErrInvalidPatch, token)
+}
+
+// removeValue returns the document without the value at path and the value.
+func removeValue(target interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: can't remove the whole document", ErrInvalidPatch)
+	}
+
+	token := path[0]
+
+	switch node := target.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %q doesn't exist", ErrInvalidPatch, token)
+		}
+
+		if len(path) == 1 {
+			delete(node, token)
+			return node, child, nil
+		}
+
+		child, removed, err := removeValue(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = child
+
+		return node, removed, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(path) == 1 {
+			removed := node[index]
+			return append(node[:index], node[index+1:]...), removed, nil
+		}
+
+		child, removed, err := removeValue(node[index], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[index] = child
+
+		return node, removed, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: %q doesn't exist", ErrInvalidPatch, token)
+}
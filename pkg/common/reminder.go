@@ -0,0 +1,117 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// reminderBatchSize caps how many reminders a single tick enqueues, so one
+// replica doesn't hold on to the whole backlog.
+const reminderBatchSize = 100
+
+// RunReminderScheduler periodically enqueues reminder emails for todos whose
+// reminder time has passed. It blocks until ctx is cancelled.
+func RunReminderScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := SendDueReminders(ctx)
+			if err != nil {
+				zap.L().Error("Failed to send due reminders", zap.Error(err))
+			}
+			if sent > 0 {
+				zap.L().Info("Sent due reminders", zap.Int("count", sent))
+			}
+		}
+	}
+}
+
+// SendDueReminders enqueues every pending reminder, one todo per transaction.
+// The todo row stays locked until its delivery is recorded, so replicas
+// running concurrently skip it. The email goes out through the outbox with
+// the same transaction, so a reminder is either recorded and enqueued, or
+// neither and retried on the next tick.
+func SendDueReminders(ctx context.Context) (int, error) {
+	sent := 0
+
+	for sent < reminderBatchSize {
+		err := DB.WithContext(ctx).Transaction(sendNextReminder)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return sent, nil
+		}
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+func sendNextReminder(tx *gorm.DB) error {
+	var todo entities.Todo
+
+	result := tx.
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("remind_at IS NOT NULL AND remind_at <= ? AND completed_at IS NULL", time.Now()).
+		Where("NOT EXISTS (?)", tx.Session(&gorm.Session{NewDB: true}).
+			Model(&entities.TodoReminder{}).
+			Select("1").
+			Where("todo_reminders.todo_id = todos.id AND todo_reminders.remind_at = todos.remind_at"),
+		).
+		Order("remind_at").
+		First(&todo)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	var user entities.User
+	result = tx.First(&user, todo.UserID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to find owner of todo %d: %w", todo.ID, result.Error)
+	}
+
+	result = tx.Create(&entities.TodoReminder{
+		TodoID:   todo.ID,
+		RemindAt: *todo.RemindAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	err := EnqueueEvent(tx,
+		models.TodoReminderDue,
+		strconv.FormatUint(todo.ID, 10),
+		models.TodoReminderDuePayload{
+			TodoID:      todo.ID,
+			Email:       user.Email,
+			Locale:      user.Locale,
+			Description: todo.Description,
+			DueAt:       todo.DueAt,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue reminder for todo %d: %w", todo.ID, err)
+	}
+
+	zap.L().Info("Enqueued reminder email",
+		zap.Uint64("todo ID", todo.ID),
+		zap.String("email", user.Email),
+	)
+
+	return nil
+}
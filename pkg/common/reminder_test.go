@@ -0,0 +1,163 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func seedReminder(t *testing.T, db *gorm.DB, completed bool) entities.Todo {
+	user := entities.User{Email: "user@example.com", Name: "User", Locale: "en"}
+	assert.NoError(t, db.Create(&user).Error)
+
+	remindAt := time.Now().Add(-time.Minute)
+	todo := entities.Todo{
+		Description: "Water the plants",
+		UserID:      user.ID,
+		RemindAt:    &remindAt,
+	}
+	if completed {
+		todo.CompletedAt = &remindAt
+	}
+	assert.NoError(t, db.Create(&todo).Error)
+
+	return todo
+}
+
+func enqueuedReminders(t *testing.T, db *gorm.DB) []models.TodoReminderDuePayload {
+	var outbox []entities.OutboxEvent
+	assert.NoError(t, db.Where("event_type = ?", models.TodoReminderDue).Find(&outbox).Error)
+
+	payloads := make([]models.TodoReminderDuePayload, 0, len(outbox))
+	for _, row := range outbox {
+		var event models.Event
+		assert.NoError(t, json.Unmarshal([]byte(row.Value), &event))
+
+		var payload models.TodoReminderDuePayload
+		assert.NoError(t, common.DecodePayload(event, &payload))
+		payloads = append(payloads, payload)
+	}
+
+	return payloads
+}
+
+func TestSendDueRemindersSendsOnce(t *testing.T) {
+	db := common.SetupTestDB()
+	common.SetDB(db)
+
+	todo := seedReminder(t, db, false)
+
+	sent, err := common.SendDueReminders(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	sent, err = common.SendDueReminders(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	reminders := enqueuedReminders(t, db)
+	if assert.Len(t, reminders, 1) {
+		assert.Equal(t, todo.ID, reminders[0].TodoID)
+		assert.Equal(t, "user@example.com", reminders[0].Email)
+		assert.Equal(t, "Water the plants", reminders[0].Description)
+	}
+}
+
+func TestSendDueRemindersSkipsCompletedTodos(t *testing.T) {
+	db := common.SetupTestDB()
+	common.SetDB(db)
+
+	seedReminder(t, db, true)
+
+	sent, err := common.SendDueReminders(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, enqueuedReminders(t, db))
+}
+
+func TestSendDueRemindersSendsAgainForNewReminderTime(t *testing.T) {
+	db := common.SetupTestDB()
+	common.SetDB(db)
+
+	todo := seedReminder(t, db, false)
+
+	sent, err := common.SendDueReminders(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	assert.NoError(t, db.Model(&todo).Update("remind_at", time.Now().Add(-time.Second)).Error)
+
+	sent, err = common.SendDueReminders(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, enqueuedReminders(t, db), 2)
+}
+
+func TestSendDueRemindersNotResentAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reminders.db")
+	open := func() *gorm.DB {
+		db, err := gorm.Open(sqlite.Open(path), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, db.AutoMigrate(
+			&entities.User{},
+			&entities.Todo{},
+			&entities.TodoReminder{},
+			&entities.OutboxEvent{},
+		))
+		return db
+	}
+
+	db := open()
+	common.SetDB(db)
+	seedReminder(t, db, false)
+
+	sent, err := common.SendDueReminders(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	assert.NoError(t, sqlDB.Close())
+
+	// A fresh process only knows what the database recorded
+	db = open()
+	common.SetDB(db)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	sent, err = common.SendDueReminders(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Len(t, enqueuedReminders(t, db), 1)
+}
+
+func TestSendDueRemindersRollsBackWithoutOutbox(t *testing.T) {
+	db := common.SetupTestDB()
+	common.SetDB(db)
+
+	seedReminder(t, db, false)
+	assert.NoError(t, db.Migrator().DropTable(&entities.OutboxEvent{}))
+
+	sent, err := common.SendDueReminders(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 0, sent)
+
+	// The reminder isn't recorded, so it's retried on the next tick
+	var recorded int64
+	assert.NoError(t, db.Model(&entities.TodoReminder{}).Count(&recorded).Error)
+	assert.Equal(t, int64(0), recorded)
+}
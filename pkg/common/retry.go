@@ -0,0 +1,69 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	eventMaxAttempts    = 5
+	eventRetryBaseDelay = time.Second
+	eventRetryMaxDelay  = 30 * time.Second
+)
+
+// retryAfter waits out the delay between attempts, swapped out by tests.
+var retryAfter = time.After
+
+// permanentError marks a failure that won't go away by retrying, like a
+// payload that can't be decoded.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// retryWithBackoff calls fn until it succeeds, fails permanently, ctx is
+// cancelled or maxAttempts is reached. A maxAttempts of 0 retries forever.
+// The delay between attempts doubles up to eventRetryMaxDelay.
+func retryWithBackoff(ctx context.Context, maxAttempts int, fn func() error) (int, error) {
+	delay := eventRetryBaseDelay
+
+	for attempts := 1; ; attempts++ {
+		err := fn()
+		if err == nil || IsPermanent(err) || (maxAttempts > 0 && attempts >= maxAttempts) {
+			return attempts, err
+		}
+
+		zap.L().Warn("Attempt failed, retrying",
+			zap.Int("attempt", attempts),
+			zap.Duration("retry in", delay),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return attempts, ctx.Err()
+		case <-retryAfter(delay):
+		}
+
+		delay = min(delay*2, eventRetryMaxDelay)
+	}
+}
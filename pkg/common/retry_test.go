@@ -0,0 +1,109 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/pkg/common"
+)
+
+// skipRetryDelays records the delays between retries instead of waiting them out.
+func skipRetryDelays(t *testing.T) *[]time.Duration {
+	var delays []time.Duration
+	common.SetRetryAfter(t, func(delay time.Duration) <-chan time.Time {
+		delays = append(delays, delay)
+		fired := make(chan time.Time, 1)
+		fired <- time.Now()
+		return fired
+	})
+
+	return &delays
+}
+
+func TestRetryWithBackoffStopsAtMaxAttempts(t *testing.T) {
+	delays := skipRetryDelays(t)
+	failure := errors.New("mail server is down")
+
+	calls := 0
+	attempts, err := common.RetryWithBackoff(context.Background(), 8, func() error {
+		calls++
+		return failure
+	})
+
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, 8, attempts)
+	assert.Equal(t, 8, calls)
+	assert.Equal(t, []time.Duration{
+		1 * time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		16 * time.Second,
+		30 * time.Second,
+		30 * time.Second,
+	}, *delays)
+}
+
+func TestRetryWithBackoffStopsOnSuccess(t *testing.T) {
+	skipRetryDelays(t)
+
+	calls := 0
+	attempts, err := common.RetryWithBackoff(context.Background(), 5, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("mail server is down")
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryWithBackoffWithoutLimit(t *testing.T) {
+	skipRetryDelays(t)
+
+	calls := 0
+	attempts, err := common.RetryWithBackoff(context.Background(), 0, func() error {
+		calls++
+		if calls <= 20 {
+			return errors.New("kafka is unavailable")
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 21, attempts)
+}
+
+func TestRetryWithBackoffStopsOnPermanentError(t *testing.T) {
+	delays := skipRetryDelays(t)
+
+	attempts, err := common.RetryWithBackoff(context.Background(), 5, func() error {
+		return common.Permanent(errors.New("payload can't be decoded"))
+	})
+
+	assert.True(t, common.IsPermanent(err))
+	assert.Equal(t, 1, attempts)
+	assert.Empty(t, *delays)
+}
+
+func TestRetryWithBackoffStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The delay never passes, only the cancellation ends the wait
+	common.SetRetryAfter(t, func(time.Duration) <-chan time.Time {
+		cancel()
+		return nil
+	})
+
+	attempts, err := common.RetryWithBackoff(ctx, 0, func() error {
+		return errors.New("mail server is down")
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,154 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"gorm.io/gorm"
+)
+
+// Sessions are stored as token keys. A set per user indexes them, so every
+// session of a user can be revoked at once.
+
+func userSessionsKey(userID uint64) string {
+	return fmt.Sprintf("user_sessions:%d", userID)
+}
+
+func StoreSession(ctx context.Context, userID uint64, token string, ttl time.Duration) error {
+	err := RedisClient.Set(ctx, token, "token", ttl).Err()
+	if err != nil {
+		return err
+	}
+
+	key := userSessionsKey(userID)
+	if err := RedisClient.SAdd(ctx, key, token).Err(); err != nil {
+		return err
+	}
+
+	return RedisClient.Expire(ctx, key, ttl).Err()
+}
+
+func RemoveSession(ctx context.Context, userID uint64, token string) error {
+	err := RedisClient.Del(ctx, token).Err()
+	if err != nil {
+		return err
+	}
+
+	return RedisClient.SRem(ctx, userSessionsKey(userID), token).Err()
+}
+
+// RevokeUserSessions logs the user out everywhere.
+func RevokeUserSessions(ctx context.Context, userID uint64) error {
+	key := userSessionsKey(userID)
+
+	tokens, err := RedisClient.SMembers(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	return RedisClient.Del(ctx, append(tokens, key)...).Err()
+}
+
+// sessionTouchInterval limits how often the last seen time of a session is
+// written, so authorizing a request doesn't always write to the database.
+const sessionTouchInterval = time.Minute
+
+// sessionTouches throttles TouchSessionIfDue within this process.
+var sessionTouches = NewTouchThrottle(sessionTouchInterval)
+
+// TouchSessionIfDue is TouchSession for authorizing requests. It skips the
+// database when this process touched the session within the interval.
+func TouchSessionIfDue(db *gorm.DB, sessionID, ipAddress, userAgent string) error {
+	if !sessionTouches.Due(sessionID) {
+		return nil
+	}
+
+	return TouchSession(db, sessionID, ipAddress, userAgent)
+}
+
+// TouchSession records that a session was just used.
+func TouchSession(db *gorm.DB, sessionID, ipAddress, userAgent string) error {
+	now := time.Now()
+
+	return db.Model(&entities.Session{}).
+		Where("id = ? AND last_seen_at < ?", sessionID, now.Add(-sessionTouchInterval)).
+		Updates(map[string]interface{}{
+			"last_seen_at": now,
+			"ip_address":   ipAddress,
+			"user_agent":   userAgent,
+		}).Error
+}
+
+// RevokeSession revokes a session of the user. It reports whether an active
+// session was found.
+func RevokeSession(ctx context.Context, db *gorm.DB, userID uint64, sessionID string) (bool, error) {
+	result := db.Model(&entities.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	return true, AddToRevocationList(ctx, sessionID)
+}
+
+// RevokeAllSessions revokes every session and access token of the user.
+func RevokeAllSessions(ctx context.Context, db *gorm.DB, userID uint64) error {
+	var sessionIDs []string
+	err := db.Model(&entities.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Pluck("id", &sessionIDs).Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Model(&entities.Session{}).
+		Where("id IN ?", sessionIDs).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	if err := AddToRevocationList(ctx, sessionIDs...); err != nil {
+		return err
+	}
+
+	return RevokeUserSessions(ctx, userID)
+}
+
+// Access tokens are verified locally by other services, so revoking a session
+// in the database is not enough. Its ID is listed in Redis until the access
+// tokens issued for it have expired.
+
+// revocationTTL is the lifetime of access tokens. It's read on first use, so
+// importing the package doesn't need the environment.
+var revocationTTL = sync.OnceValue(func() time.Duration {
+	return ParseDuration("ACCESS_TOKEN_TTL", GetEnvironmentVariables().AccessTokenTTL)
+})
+
+func revokedSessionKey(sessionID string) string {
+	return fmt.Sprintf("revoked_session:%s", sessionID)
+}
+
+func AddToRevocationList(ctx context.Context, sessionIDs ...string) error {
+	for _, sessionID := range sessionIDs {
+		err := RedisClient.Set(ctx, revokedSessionKey(sessionID), "revoked", revocationTTL()).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	count, err := RedisClient.Exists(ctx, revokedSessionKey(sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,24 @@
+package common
+
+import (
+	"errors"
+
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// TodoSharePermission returns the permission the todo is shared with the
+// user, or the empty permission if it isn't shared with them.
+func TodoSharePermission(db *gorm.DB, todoID, userID uint64) (models.SharePermission, error) {
+	var share entities.TodoShare
+	result := db.Where("todo_id = ? AND user_id = ?", todoID, userID).First(&share)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if result.Error != nil {
+		return "", result.Error
+	}
+
+	return models.SharePermission(share.Permission), nil
+}
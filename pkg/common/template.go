@@ -0,0 +1,113 @@
+package common
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+const DefaultLocale = "en"
+
+// Mail templates, one directory per locale. Every message has a .txt.tmpl
+// and a .html.tmpl file defining "subject" and "content", which are rendered
+// inside the shared layout.
+//
+//go:embed templates
+var templateFS embed.FS
+
+type mailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+var templateFuncs = map[string]interface{}{
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04 MST")
+	},
+}
+
+// mailTemplates is keyed by locale and then by message name.
+var mailTemplates = mustParseMailTemplates()
+
+func mustParseMailTemplates() map[string]map[string]mailTemplate {
+	templates := make(map[string]map[string]mailTemplate)
+
+	locales, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, locale := range locales {
+		if !locale.IsDir() {
+			continue
+		}
+
+		names, err := fs.Glob(templateFS, fmt.Sprintf("templates/%s/*.txt.tmpl", locale.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		templates[locale.Name()] = make(map[string]mailTemplate)
+		for _, name := range names {
+			name = strings.TrimSuffix(name[strings.LastIndex(name, "/")+1:], ".txt.tmpl")
+			prefix := fmt.Sprintf("templates/%s/%s", locale.Name(), name)
+
+			templates[locale.Name()][name] = mailTemplate{
+				html: htmltemplate.Must(htmltemplate.New(name).Funcs(templateFuncs).
+					ParseFS(templateFS, "templates/layout.html.tmpl", prefix+".html.tmpl")),
+				text: texttemplate.Must(texttemplate.New(name).Funcs(templateFuncs).
+					ParseFS(templateFS, "templates/layout.txt.tmpl", prefix+".txt.tmpl")),
+			}
+		}
+	}
+
+	return templates
+}
+
+// findTemplate falls back from "pt-BR" to "pt" and then to the default locale.
+func findTemplate(locale, name string) (mailTemplate, bool) {
+	candidates := []string{locale}
+	if base, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, base)
+	}
+	candidates = append(candidates, DefaultLocale)
+
+	for _, candidate := range candidates {
+		if template, ok := mailTemplates[strings.ToLower(candidate)][name]; ok {
+			return template, true
+		}
+	}
+
+	return mailTemplate{}, false
+}
+
+// RenderMail renders the named message in the recipient's locale.
+func RenderMail(to, locale, name string, data interface{}) (Mail, error) {
+	template, ok := findTemplate(locale, name)
+	if !ok {
+		return Mail{}, fmt.Errorf("mail template not found: %s", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := template.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Mail{}, err
+	}
+	if err := template.text.ExecuteTemplate(&text, "layout", data); err != nil {
+		return Mail{}, err
+	}
+	if err := template.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Mail{}, err
+	}
+
+	return Mail{
+		To:       to,
+		Subject:  strings.TrimSpace(subject.String()),
+		TextPart: text.String(),
+		HTMLPart: html.String(),
+	}, nil
+}
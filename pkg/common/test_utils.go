@@ -18,7 +18,30 @@ func SetupTestDB() *gorm.DB {
 		panic(err)
 	}
 
-	err = db.AutoMigrate(&entities.User{}, &entities.Todo{})
+	err = db.AutoMigrate(
+		&entities.User{},
+		&entities.Todo{},
+		&entities.TodoReminder{},
+		&entities.OutboxEvent{},
+		&entities.PasswordResetToken{},
+		&entities.EmailChangeToken{},
+		&entities.Session{},
+		&entities.RefreshToken{},
+		&entities.SigningKey{},
+		&entities.RecoveryCode{},
+		&entities.PersonalAccessToken{},
+		&entities.Organization{},
+		&entities.OrganizationMember{},
+		&entities.OrganizationInvitation{},
+		&entities.TodoShare{},
+		&entities.Tag{},
+		&entities.TodoTag{},
+		&entities.Project{},
+		&entities.ChecklistItem{},
+		&entities.Workflow{},
+		&entities.WorkflowState{},
+		&entities.WorkflowTransition{},
+	)
 	if err != nil {
 		zap.L().Error("Failed to migrate tables", zap.Error(err))
 		panic(err)
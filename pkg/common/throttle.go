@@ -0,0 +1,48 @@
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+// TouchThrottle remembers when this process last recorded the use of a
+// session or token, so requests in between don't reach the database at all.
+type TouchThrottle struct {
+	interval time.Duration
+	mu       sync.Mutex
+	touched  map[string]time.Time
+	prunedAt time.Time
+}
+
+func NewTouchThrottle(interval time.Duration) *TouchThrottle {
+	return &TouchThrottle{
+		interval: interval,
+		touched:  map[string]time.Time{},
+	}
+}
+
+// Due reports whether key wasn't touched within the interval, and counts it
+// as touched now if so.
+func (t *TouchThrottle) Due(key string) bool {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.touched[key]) < t.interval {
+		return false
+	}
+	t.touched[key] = now
+
+	// Forget keys that are due anyway, at most once per interval
+	if now.Sub(t.prunedAt) >= t.interval {
+		for k, at := range t.touched {
+			if now.Sub(at) >= t.interval {
+				delete(t.touched, k)
+			}
+		}
+		t.prunedAt = now
+	}
+
+	return true
+}
@@ -0,0 +1,22 @@
+package common_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/pkg/common"
+)
+
+func TestTouchThrottle(t *testing.T) {
+	throttle := common.NewTouchThrottle(50 * time.Millisecond)
+
+	assert.True(t, throttle.Due("session-1"))
+	assert.False(t, throttle.Due("session-1"))
+	assert.True(t, throttle.Due("session-2"))
+
+	time.Sleep(60 * time.Millisecond)
+
+	assert.True(t, throttle.Due("session-1"))
+	assert.False(t, throttle.Due("session-1"))
+}
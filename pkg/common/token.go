@@ -0,0 +1,73 @@
+package common
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"gorm.io/gorm"
+)
+
+// PersonalAccessTokenPrefix tells personal access tokens apart from access
+// tokens, and makes leaked tokens easy to find.
+const PersonalAccessTokenPrefix = "tdp_"
+
+// tokenPrefixLength is how much of a token is kept to identify it.
+const tokenPrefixLength = 12
+
+// tokenTouchInterval limits how often the last used time of a personal
+// access token is written, like sessionTouchInterval for sessions.
+const tokenTouchInterval = time.Minute
+
+// tokenTouches throttles the last used writes within this process.
+var tokenTouches = NewTouchThrottle(tokenTouchInterval)
+
+var ErrInvalidPersonalAccessToken = errors.New("personal access token is invalid or expired")
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// GeneratePersonalAccessToken returns a new token and its displayable prefix.
+func GeneratePersonalAccessToken() (token, prefix string, err error) {
+	secret, err := GenerateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	token = PersonalAccessTokenPrefix + secret
+	return token, token[:tokenPrefixLength], nil
+}
+
+func AuthenticatePersonalAccessToken(db *gorm.DB, token string) (*entities.PersonalAccessToken, error) {
+	var pat entities.PersonalAccessToken
+	result := db.Where("token_hash = ?", HashToken(token)).First(&pat)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidPersonalAccessToken
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if pat.ExpiresAt != nil && pat.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidPersonalAccessToken
+	}
+
+	if tokenTouches.Due(pat.TokenHash) {
+		now := time.Now()
+
+		err := db.Model(&entities.PersonalAccessToken{}).
+			Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", pat.ID, now.Add(-tokenTouchInterval)).
+			Update("last_used_at", now).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &pat, nil
+}
+
+func TokenScopes(pat *entities.PersonalAccessToken) []string {
+	return strings.Fields(pat.Scopes)
+}
@@ -0,0 +1,75 @@
+package common_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whitehead421/todo-backend/pkg/common"
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"gorm.io/gorm"
+)
+
+func createPersonalAccessToken(t *testing.T, db *gorm.DB, lastUsedAt *time.Time) (string, entities.PersonalAccessToken) {
+	token, prefix, err := common.GeneratePersonalAccessToken()
+	assert.NoError(t, err)
+
+	pat := entities.PersonalAccessToken{
+		UserID:      1,
+		Name:        "CI",
+		TokenPrefix: prefix,
+		TokenHash:   common.HashToken(token),
+		LastUsedAt:  lastUsedAt,
+	}
+	assert.NoError(t, db.Create(&pat).Error)
+
+	return token, pat
+}
+
+func lastUsedAt(t *testing.T, db *gorm.DB, id uint64) *time.Time {
+	var pat entities.PersonalAccessToken
+	assert.NoError(t, db.First(&pat, id).Error)
+	return pat.LastUsedAt
+}
+
+func TestAuthenticatePersonalAccessTokenThrottlesLastUsed(t *testing.T) {
+	db := common.SetupTestDB()
+
+	token, pat := createPersonalAccessToken(t, db, nil)
+
+	_, err := common.AuthenticatePersonalAccessToken(db, token)
+	assert.NoError(t, err)
+
+	assert.NotNil(t, lastUsedAt(t, db, pat.ID))
+
+	// Within the interval the process doesn't even reach the database
+	longAgo := time.Now().Add(-time.Hour)
+	assert.NoError(t, db.Model(&pat).Update("last_used_at", longAgo).Error)
+
+	_, err = common.AuthenticatePersonalAccessToken(db, token)
+	assert.NoError(t, err)
+	assert.True(t, longAgo.Equal(*lastUsedAt(t, db, pat.ID)))
+}
+
+func TestAuthenticatePersonalAccessTokenSkipsRecentlyUsed(t *testing.T) {
+	db := common.SetupTestDB()
+
+	// Another replica recorded the use a moment ago
+	recently := time.Now().Add(-10 * time.Second)
+	token, pat := createPersonalAccessToken(t, db, &recently)
+
+	_, err := common.AuthenticatePersonalAccessToken(db, token)
+	assert.NoError(t, err)
+	assert.True(t, recently.Equal(*lastUsedAt(t, db, pat.ID)))
+}
+
+func TestAuthenticatePersonalAccessTokenRecordsStaleUse(t *testing.T) {
+	db := common.SetupTestDB()
+
+	longAgo := time.Now().Add(-time.Hour)
+	token, pat := createPersonalAccessToken(t, db, &longAgo)
+
+	_, err := common.AuthenticatePersonalAccessToken(db, token)
+	assert.NoError(t, err)
+	assert.True(t, lastUsedAt(t, db, pat.ID).After(longAgo))
+}
@@ -0,0 +1,25 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReserveVerificationResend allows one activation email per address and
+// interval. When one was sent recently, it returns how long to wait.
+func ReserveVerificationResend(ctx context.Context, email string, interval time.Duration) (time.Duration, error) {
+	key := fmt.Sprintf("verify_resend:%s", normalizeEmail(email))
+
+	reserved, err := RedisClient.SetNX(ctx, key, 1, interval).Result()
+	if err != nil || reserved {
+		return 0, err
+	}
+
+	wait, err := RedisClient.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return max(wait, time.Second), nil
+}
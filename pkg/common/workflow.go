@@ -0,0 +1,153 @@
+package common
+
+import (
+	"errors"
+
+	"github.com/whitehead421/todo-backend/pkg/entities"
+	"github.com/whitehead421/todo-backend/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FindWorkflow returns the workflow of the project, or the default workflow
+// of the user without a project. It returns false when there is none.
+func FindWorkflow(db *gorm.DB, userID uint64, projectID *uint64) (models.Workflow, bool, error) {
+	var workflow entities.Workflow
+
+	query := db.Where("user_id = ? AND project_id IS NULL", userID)
+	if projectID != nil {
+		query = db.Where("user_id = ? AND project_id = ?", userID, *projectID)
+	}
+
+	result := query.First(&workflow)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.Workflow{}, false, nil
+	}
+	if result.Error != nil {
+		return models.Workflow{}, false, result.Error
+	}
+
+	var states []entities.WorkflowState
+	if err := db.Where("workflow_id = ?", workflow.ID).Order("position").Find(&states).Error; err != nil {
+		return models.Workflow{}, false, err
+	}
+
+	var transitions []entities.WorkflowTransition
+	if err := db.Where("workflow_id = ?", workflow.ID).Order("position").Find(&transitions).Error; err != nil {
+		return models.Workflow{}, false, err
+	}
+
+	response := models.Workflow{}
+	for _, state := range states {
+		response.States = append(response.States, models.WorkflowState{
+			Name:     state.Name,
+			Category: models.Status(state.Category),
+		})
+	}
+	for _, transition := range transitions {
+		response.Transitions = append(response.Transitions, models.WorkflowTransition{
+			From: transition.FromState,
+			To:   transition.ToState,
+		})
+	}
+
+	return response, true, nil
+}
+
+// EffectiveWorkflow returns the workflow todos of the user in the project
+// follow: the one of the project, the default of the user, or the built-in
+// one.
+func EffectiveWorkflow(db *gorm.DB, userID uint64, projectID *uint64) (models.Workflow, bool, error) {
+	if projectID != nil {
+		workflow, found, err := FindWorkflow(db, userID, projectID)
+		if err != nil || found {
+			return workflow, found, err
+		}
+	}
+
+	workflow, found, err := FindWorkflow(db, userID, nil)
+	if err != nil || found {
+		return workflow, found, err
+	}
+
+	return models.DefaultWorkflow, false, nil
+}
+
+// TodoWorkflow returns the workflow of a todo. Todos of an organization
+// always follow the built-in workflow, private ones the workflow of their
+// creator.
+func TodoWorkflow(db *gorm.DB, todo entities.Todo) (models.Workflow, error) {
+	if todo.OrganizationID != nil {
+		return models.DefaultWorkflow, nil
+	}
+
+	workflow, _, err := EffectiveWorkflow(db, todo.UserID, todo.ProjectID)
+	return workflow, err
+}
+
+// SaveWorkflow replaces the workflow of the project, or the default workflow
+// of the user without a project.
+func SaveWorkflow(db *gorm.DB, userID uint64, projectID *uint64, workflow models.Workflow) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := DeleteWorkflow(tx, userID, projectID); err != nil {
+			return err
+		}
+
+		saved := entities.Workflow{UserID: userID, ProjectID: projectID}
+		if err := tx.Create(&saved).Error; err != nil {
+			return err
+		}
+
+		for position, state := range workflow.States {
+			err := tx.Create(&entities.WorkflowState{
+				WorkflowID: saved.ID,
+				Name:       state.Name,
+				Category:   string(state.Category),
+				Position:   position,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		for position, transition := range workflow.Transitions {
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entities.WorkflowTransition{
+				WorkflowID: saved.ID,
+				FromState:  transition.From,
+				ToState:    transition.To,
+				Position:   position,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteWorkflow removes the workflow of the project, or the default workflow
+// of the user without a project.
+func DeleteWorkflow(db *gorm.DB, userID uint64, projectID *uint64) error {
+	query := db.Model(&entities.Workflow{}).Where("user_id = ? AND project_id IS NULL", userID)
+	if projectID != nil {
+		query = db.Model(&entities.Workflow{}).Where("user_id = ? AND project_id = ?", userID, *projectID)
+	}
+
+	var workflowIDs []uint64
+	if err := query.Pluck("id", &workflowIDs).Error; err != nil {
+		return err
+	}
+	if len(workflowIDs) == 0 {
+		return nil
+	}
+
+	if err := db.Where("workflow_id IN ?", workflowIDs).Delete(&entities.WorkflowTransition{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("workflow_id IN ?", workflowIDs).Delete(&entities.WorkflowState{}).Error; err != nil {
+		return err
+	}
+
+	return db.Where("id IN ?", workflowIDs).Delete(&entities.Workflow{}).Error
+}
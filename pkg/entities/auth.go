@@ -5,12 +5,51 @@ import (
 )
 
 type User struct {
-	ID          uint64    `gorm:"column:id;primary_key;auto_increment"`
-	Email       string    `gorm:"column:email"`
-	Name        string    `gorm:"column:name"`
-	Password    string    `gorm:"column:password"`
-	Verified    bool      `gorm:"column:verified"`
-	VerifyToken string    `gorm:"column:verify_token"`
-	CreatedAt   time.Time `gorm:"column:created_at"`
-	UpdatedAt   time.Time `gorm:"column:updated_at"`
+	ID                   uint64     `gorm:"column:id;primary_key;auto_increment"`
+	Email                string     `gorm:"column:email"`
+	Name                 string     `gorm:"column:name"`
+	Password             string     `gorm:"column:password"`
+	Verified             bool       `gorm:"column:verified"`
+	VerifyToken          string     `gorm:"column:verify_token"`
+	VerifyTokenExpiresAt *time.Time `gorm:"column:verify_token_expires_at"`
+	Locale               string     `gorm:"column:locale;default:en"`
+	TotpSecret           string     `gorm:"column:totp_secret"`
+	TotpEnabled          bool       `gorm:"column:totp_enabled"`
+	TotpLastStep         int64      `gorm:"column:totp_last_step"`
+	CreatedAt            time.Time  `gorm:"column:created_at"`
+	UpdatedAt            time.Time  `gorm:"column:updated_at"`
+}
+
+// PasswordResetToken is a single use token emailed to reset a forgotten
+// password. Only the hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uint64     `gorm:"column:id;primary_key;auto_increment"`
+	UserID    uint64     `gorm:"column:user_id"`
+	TokenHash string     `gorm:"column:token_hash;unique"`
+	ExpiresAt time.Time  `gorm:"column:expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+}
+
+// EmailChangeToken records a pending email address. The address is only
+// changed once the token emailed to it is confirmed. Only the hash of the
+// token is stored.
+type EmailChangeToken struct {
+	ID        uint64     `gorm:"column:id;primary_key;auto_increment"`
+	UserID    uint64     `gorm:"column:user_id"`
+	NewEmail  string     `gorm:"column:new_email"`
+	TokenHash string     `gorm:"column:token_hash;unique"`
+	ExpiresAt time.Time  `gorm:"column:expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+}
+
+// RecoveryCode is a single use code to pass two-factor authentication without
+// the authenticator. Only the hash of the code is stored.
+type RecoveryCode struct {
+	ID        uint64     `gorm:"column:id;primary_key;auto_increment"`
+	UserID    uint64     `gorm:"column:user_id"`
+	CodeHash  string     `gorm:"column:code_hash"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
 }
@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"time"
+)
+
+// ChecklistItem is a step of a todo. Items are ordered by position.
+type ChecklistItem struct {
+	ID          uint64    `gorm:"column:id;primary_key;auto_increment"`
+	TodoID      uint64    `gorm:"column:todo_id"`
+	Description string    `gorm:"column:description"`
+	Done        bool      `gorm:"column:done"`
+	Position    int       `gorm:"column:position"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at"`
+}
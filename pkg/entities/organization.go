@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+)
+
+// Organization is a team sharing todos. What members may do depends on their
+// role.
+type Organization struct {
+	ID        uint64    `gorm:"column:id;primary_key;auto_increment"`
+	Name      string    `gorm:"column:name"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+type OrganizationMember struct {
+	OrganizationID uint64    `gorm:"column:organization_id;primary_key"`
+	UserID         uint64    `gorm:"column:user_id;primary_key"`
+	Role           string    `gorm:"column:role"`
+	CreatedAt      time.Time `gorm:"column:created_at"`
+}
+
+// OrganizationInvitation is emailed to join an organization. It can only be
+// accepted by the account with the invited email. Only the hash of the token
+// is stored.
+type OrganizationInvitation struct {
+	ID             uint64     `gorm:"column:id;primary_key;auto_increment"`
+	OrganizationID uint64     `gorm:"column:organization_id"`
+	Email          string     `gorm:"column:email"`
+	Role           string     `gorm:"column:role"`
+	TokenHash      string     `gorm:"column:token_hash;unique"`
+	InvitedBy      *uint64    `gorm:"column:invited_by"`
+	ExpiresAt      time.Time  `gorm:"column:expires_at"`
+	AcceptedAt     *time.Time `gorm:"column:accepted_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at"`
+}
@@ -0,0 +1,19 @@
+package entities
+
+import (
+	"time"
+)
+
+// OutboxEvent is an event waiting to be published to Kafka. It is written in
+// the same transaction as the change it describes.
+type OutboxEvent struct {
+	ID         uint64     `gorm:"column:id;primary_key;auto_increment"`
+	EventID    string     `gorm:"column:event_id"`
+	EventType  string     `gorm:"column:event_type"`
+	MessageKey string     `gorm:"column:message_key"`
+	Value      string     `gorm:"column:value"`
+	Attempts   int        `gorm:"column:attempts"`
+	LastError  string     `gorm:"column:last_error"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+	SentAt     *time.Time `gorm:"column:sent_at"`
+}
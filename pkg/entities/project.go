@@ -0,0 +1,18 @@
+package entities
+
+import (
+	"time"
+)
+
+// Project groups the private todos of a user. Todos without a project are in
+// the inbox.
+type Project struct {
+	ID        uint64    `gorm:"column:id;primary_key;auto_increment"`
+	UserID    uint64    `gorm:"column:user_id"`
+	Name      string    `gorm:"column:name"`
+	Color     string    `gorm:"column:color"`
+	Archived  bool      `gorm:"column:archived"`
+	Position  int       `gorm:"column:position"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
@@ -0,0 +1,14 @@
+package entities
+
+import (
+	"time"
+)
+
+// TodoReminder records a reminder that was delivered for a todo. RemindAt is
+// part of the key, so moving the reminder of a todo schedules a new one.
+type TodoReminder struct {
+	ID       uint64    `gorm:"column:id;primary_key;auto_increment"`
+	TodoID   uint64    `gorm:"column:todo_id;uniqueIndex:uq_todo_reminders_todo_id_remind_at"`
+	RemindAt time.Time `gorm:"column:remind_at;uniqueIndex:uq_todo_reminders_todo_id_remind_at"`
+	SentAt   time.Time `gorm:"column:sent_at;autoCreateTime"`
+}
@@ -0,0 +1,29 @@
+package entities
+
+import (
+	"time"
+)
+
+// Session is a login of a user. Its refresh tokens form a family, replaying a
+// used refresh token revokes the whole session.
+type Session struct {
+	ID         string     `gorm:"column:id;primary_key"`
+	UserID     uint64     `gorm:"column:user_id"`
+	UserAgent  string     `gorm:"column:user_agent"`
+	IPAddress  string     `gorm:"column:ip_address"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+}
+
+// RefreshToken is an opaque, single use token to get a new access token.
+// Only the hash of the token is stored.
+type RefreshToken struct {
+	ID        uint64     `gorm:"column:id;primary_key;auto_increment"`
+	SessionID string     `gorm:"column:session_id"`
+	TokenHash string     `gorm:"column:token_hash;unique"`
+	ExpiresAt time.Time  `gorm:"column:expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+}
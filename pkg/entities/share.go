@@ -0,0 +1,13 @@
+package entities
+
+import (
+	"time"
+)
+
+// TodoShare gives another user access to a single todo.
+type TodoShare struct {
+	TodoID     uint64    `gorm:"column:todo_id;primary_key"`
+	UserID     uint64    `gorm:"column:user_id;primary_key"`
+	Permission string    `gorm:"column:permission"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+}
@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"time"
+)
+
+// SigningKey is a key of the keyring access tokens are signed with. The newest
+// active key signs, older keys only verify until ExpiresAt.
+type SigningKey struct {
+	KeyID       string     `gorm:"column:kid;primary_key"`
+	Algorithm   string     `gorm:"column:algorithm"`
+	PrivateKey  string     `gorm:"column:private_key"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+	ActivatesAt time.Time  `gorm:"column:activates_at"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at"`
+}
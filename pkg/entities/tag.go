@@ -0,0 +1,21 @@
+package entities
+
+import (
+	"time"
+)
+
+// Tag labels todos. Tags are personal, every user has their own set, and
+// todos refer to them by id so renames show up everywhere.
+type Tag struct {
+	ID        uint64    `gorm:"column:id;primary_key;auto_increment"`
+	UserID    uint64    `gorm:"column:user_id;uniqueIndex:uq_tags_user_id_name"`
+	Name      string    `gorm:"column:name;uniqueIndex:uq_tags_user_id_name"`
+	Color     string    `gorm:"column:color"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+type TodoTag struct {
+	TodoID uint64 `gorm:"column:todo_id;primary_key"`
+	TagID  uint64 `gorm:"column:tag_id;primary_key"`
+}
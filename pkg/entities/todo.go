@@ -4,11 +4,19 @@ import (
 	"time"
 )
 
+// Todo is created by UserID. Todos of an organization are shared with its
+// members, the others are private to their creator and can be grouped in one
+// of their projects.
 type Todo struct {
-	ID          uint64    `gorm:"column:id;primary_key;auto_increment"`
-	Status      string    `gorm:"column:status"`
-	Description string    `gorm:"column:description"`
-	UserID      uint64    `gorm:"column:user_id"`
-	CreatedAt   time.Time `gorm:"column:created_at"`
-	UpdatedAt   time.Time `gorm:"column:updated_at"`
+	ID             uint64     `gorm:"column:id;primary_key;auto_increment"`
+	Status         string     `gorm:"column:status"`
+	Description    string     `gorm:"column:description"`
+	UserID         uint64     `gorm:"column:user_id"`
+	OrganizationID *uint64    `gorm:"column:organization_id"`
+	ProjectID      *uint64    `gorm:"column:project_id"`
+	DueAt          *time.Time `gorm:"column:due_at"`
+	RemindAt       *time.Time `gorm:"column:remind_at"`
+	CompletedAt    *time.Time `gorm:"column:completed_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at"`
 }
@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+)
+
+// PersonalAccessToken is a long lived token for scripts, limited to scopes.
+// Scopes are space separated. Only the hash of the token is stored, the
+// prefix identifies it in listings.
+type PersonalAccessToken struct {
+	ID          uint64     `gorm:"column:id;primary_key;auto_increment"`
+	UserID      uint64     `gorm:"column:user_id"`
+	Name        string     `gorm:"column:name"`
+	TokenHash   string     `gorm:"column:token_hash;unique"`
+	TokenPrefix string     `gorm:"column:token_prefix"`
+	Scopes      string     `gorm:"column:scopes"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at"`
+	LastUsedAt  *time.Time `gorm:"column:last_used_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+}
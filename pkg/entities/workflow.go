@@ -0,0 +1,29 @@
+package entities
+
+import (
+	"time"
+)
+
+// Workflow is a custom state machine for todo statuses. Without ProjectID it
+// is the default of the user, otherwise it only applies to the project.
+type Workflow struct {
+	ID        uint64    `gorm:"column:id;primary_key;auto_increment"`
+	UserID    uint64    `gorm:"column:user_id"`
+	ProjectID *uint64   `gorm:"column:project_id;unique"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+type WorkflowState struct {
+	WorkflowID uint64 `gorm:"column:workflow_id;primary_key"`
+	Name       string `gorm:"column:name;primary_key"`
+	Category   string `gorm:"column:category"`
+	Position   int    `gorm:"column:position"`
+}
+
+type WorkflowTransition struct {
+	WorkflowID uint64 `gorm:"column:workflow_id;primary_key"`
+	FromState  string `gorm:"column:from_state;primary_key"`
+	ToState    string `gorm:"column:to_state;primary_key"`
+	Position   int    `gorm:"column:position"`
+}
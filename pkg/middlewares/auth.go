@@ -1,53 +1,82 @@
 package middlewares
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/whitehead421/todo-backend/pkg/common"
-	"github.com/whitehead421/todo-backend/pkg/models"
 	"go.uber.org/zap"
 )
 
+// AuthenticationMiddleware verifies access tokens locally with the key set
+// published by the auth service. Only the revocation list is consulted per
+// request, for sessions that were logged out before their tokens expired.
 func AuthenticationMiddleware() gin.HandlerFunc {
 	env := common.GetEnvironmentVariables()
-	path := fmt.Sprintf("http://auth:%s/authorize", env.AuthPort)
+	path := fmt.Sprintf("http://auth:%s/.well-known/jwks.json", env.AuthPort)
+	keys := common.NewJWKSCache(path, common.ParseDuration("JWKS_CACHE_TTL", env.JwksCacheTTL))
 
 	return func(c *gin.Context) {
-		token := c.Request.Header.Get("Authorization")
-		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		authHeader := c.Request.Header.Get("Authorization")
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if authHeader == "" || tokenString == authHeader {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
 
-		req, _ := http.NewRequest("POST", path, nil)
-		req.Header.Set("Authorization", token)
+		if common.IsPersonalAccessToken(tokenString) {
+			authenticatePersonalAccessToken(c, tokenString)
+			return
+		}
 
-		resp, err := http.DefaultClient.Do(req)
+		claims, err := common.ParseToken(tokenString, keys.Keyfunc)
 		if err != nil {
-			zap.L().Error("Failed to authorize", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			zap.L().Error("Invalid token", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "status": resp.StatusCode})
-			c.Abort()
+		revoked, err := common.IsSessionRevoked(c, claims.SessionID)
+		if err != nil {
+			zap.L().Error("Failed to check revocation list", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
 			return
 		}
 
-		var authResponse models.AuthorizeResponse
-		err = json.NewDecoder(resp.Body).Decode(&authResponse)
-		if err != nil {
-			zap.L().Error("Failed to parse response", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
 
-		c.Set("userID", authResponse.UserId)
+		err = common.TouchSessionIfDue(common.DB, claims.SessionID, c.ClientIP(), c.Request.UserAgent())
+		if err != nil {
+			zap.L().Error("Failed to update session", zap.Error(err))
+		}
+
+		c.Set("userID", claims.UserID)
+		c.Set("sessionID", claims.SessionID)
 		c.Next()
 	}
 }
+
+// authenticatePersonalAccessToken lets scripts in with a personal access token.
+// The token's scopes are set on the context for RequireScopes.
+func authenticatePersonalAccessToken(c *gin.Context, token string) {
+	pat, err := common.AuthenticatePersonalAccessToken(common.DB, token)
+	if errors.Is(err, common.ErrInvalidPersonalAccessToken) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	if err != nil {
+		zap.L().Error("Failed to authenticate personal access token", zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	c.Set("userID", pat.UserID)
+	c.Set("scopes", common.TokenScopes(pat))
+	c.Next()
+}
@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScopes limits what personal access tokens can do in a route group.
+// Reads need the read scope and everything else the write scope. An empty
+// scope keeps personal access tokens out. Session tokens are not limited.
+func RequireScopes(read, write string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, ok := c.Get("scopes")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		scope := write
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = read
+		}
+
+		scopes, _ := value.([]string)
+		if scope == "" || !slices.Contains(scopes, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient scope"})
+			return
+		}
+
+		c.Next()
+	}
+}
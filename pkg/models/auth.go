@@ -5,6 +5,7 @@ type RegisterRequest struct {
 	Name     string `json:"name" validate:"required,min=4"`
 	Password string `json:"password" validate:"required,min=6,max=32,nefield=Name,nefield=Email"`
 	Confirm  string `json:"confirm" validate:"required,eqfield=Password"`
+	Locale   string `json:"locale" example:"en" validate:"omitempty,bcp47_language_tag"`
 }
 
 type RegisterResponse struct {
@@ -21,11 +22,33 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token  string `json:"token"`
-	UserId uint64 `json:"user_id"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	UserId       uint64 `json:"user_id"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6,max=32"`
+	Confirm  string `json:"confirm" validate:"required,eqfield=Password"`
 }
 
 type AuthorizeResponse struct {
-	Message string `json:"message"`
-	UserId  uint64 `json:"user_id"`
+	Message   string   `json:"message"`
+	UserId    uint64   `json:"user_id"`
+	SessionId string   `json:"session_id,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
 }
@@ -0,0 +1,26 @@
+package models
+
+type ChecklistItemRequest struct {
+	Description string `json:"description" example:"Buy flour" validate:"required,max=255"`
+	Done        bool   `json:"done"`
+}
+
+// ReorderChecklistRequest lists all items of a todo in their new order.
+type ReorderChecklistRequest struct {
+	ItemIDs []uint64 `json:"item_ids" validate:"required,min=1"`
+}
+
+type ChecklistItemResponse struct {
+	ID          uint64 `json:"id"`
+	Description string `json:"description"`
+	Done        bool   `json:"done"`
+	Position    int    `json:"position"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// ChecklistProgress counts the done items of a todo, like 3/5 done.
+type ChecklistProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
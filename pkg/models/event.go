@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	UserRegistered         EventType = "user.registered"
+	PasswordResetRequested EventType = "user.password_reset_requested"
+	AccountLocked          EventType = "user.account_locked"
+	EmailChangeRequested   EventType = "user.email_change_requested"
+	EmailChangeConfirming  EventType = "user.email_change_confirming"
+	MemberInvited          EventType = "organization.member_invited"
+	TodoReminderDue        EventType = "todo.reminder_due"
+	// VerificationRequested resends the activation email, with a UserRegisteredPayload
+	VerificationRequested EventType = "user.verification_requested"
+)
+
+// Event is the envelope every message on the Kafka topic is wrapped in.
+// SchemaVersion versions the payload of the given Type.
+type Event struct {
+	ID            string          `json:"id"`
+	Type          EventType       `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+type UserRegisteredPayload struct {
+	UserID      uint64 `json:"user_id"`
+	Email       string `json:"email"`
+	Name        string `json:"name"`
+	VerifyToken string `json:"verify_token"`
+	Locale      string `json:"locale"`
+}
+
+type PasswordResetRequestedPayload struct {
+	UserID    uint64    `json:"user_id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Locale    string    `json:"locale"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AccountLockedPayload struct {
+	UserID      uint64    `json:"user_id"`
+	Email       string    `json:"email"`
+	Name        string    `json:"name"`
+	Locale      string    `json:"locale"`
+	UnlockToken string    `json:"unlock_token"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// EmailChangeRequestedPayload notifies the current address of the change.
+type EmailChangeRequestedPayload struct {
+	UserID   uint64 `json:"user_id"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Locale   string `json:"locale"`
+	NewEmail string `json:"new_email"`
+}
+
+// EmailChangeConfirmingPayload asks the new address to confirm the change.
+type EmailChangeConfirmingPayload struct {
+	UserID    uint64    `json:"user_id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Locale    string    `json:"locale"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type MemberInvitedPayload struct {
+	OrganizationID   uint64    `json:"organization_id"`
+	OrganizationName string    `json:"organization_name"`
+	Email            string    `json:"email"`
+	Locale           string    `json:"locale"`
+	InviterName      string    `json:"inviter_name"`
+	Role             string    `json:"role"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type TodoReminderDuePayload struct {
+	TodoID      uint64     `json:"todo_id"`
+	Email       string     `json:"email"`
+	Locale      string     `json:"locale"`
+	Description string     `json:"description"`
+	DueAt       *time.Time `json:"due_at"`
+}
@@ -0,0 +1,68 @@
+package models
+
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+	RoleViewer Role = "viewer"
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer: 1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+// AtLeast reports whether the role has the permissions of min. The empty
+// role, of non-members, has none.
+func (r Role) AtLeast(min Role) bool {
+	return roleRanks[r] > 0 && roleRanks[r] >= roleRanks[min]
+}
+
+type OrganizationRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type OrganizationResponse struct {
+	ID        uint64 `json:"id"`
+	Name      string `json:"name"`
+	Role      Role   `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+type OrganizationDetailResponse struct {
+	OrganizationResponse
+	Members []MemberResponse `json:"members"`
+}
+
+type MemberResponse struct {
+	UserID   uint64 `json:"user_id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Role     Role   `json:"role"`
+	JoinedAt string `json:"joined_at"`
+}
+
+// Owners are made by promoting a member, not by invitation.
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	Role  Role   `json:"role" validate:"required,oneof=admin member viewer"`
+}
+
+type InvitationResponse struct {
+	ID        uint64 `json:"id"`
+	Email     string `json:"email"`
+	Role      Role   `json:"role"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type UpdateMemberRequest struct {
+	Role Role `json:"role" validate:"required,oneof=owner admin member viewer"`
+}
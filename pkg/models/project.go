@@ -0,0 +1,30 @@
+package models
+
+// ProjectRequest creates or replaces a project. Without a position new
+// projects go last and updated ones keep theirs.
+type ProjectRequest struct {
+	Name     string `json:"name" example:"Groceries" validate:"required,max=100"`
+	Color    string `json:"color" example:"#ff8800" validate:"omitempty,max=7,hexcolor"`
+	Archived bool   `json:"archived"`
+	Position *int   `json:"position" validate:"omitempty,min=0"`
+}
+
+type ProjectResponse struct {
+	ID        uint64 `json:"id"`
+	Name      string `json:"name"`
+	Color     string `json:"color,omitempty"`
+	Archived  bool   `json:"archived"`
+	Position  int    `json:"position"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type ProjectListQuery struct {
+	Archived bool `form:"archived"`
+}
+
+// DeleteProjectQuery chooses what happens to the todos of a deleted project,
+// they are moved to the inbox by default.
+type DeleteProjectQuery struct {
+	Todos string `form:"todos" validate:"omitempty,oneof=inbox delete"`
+}
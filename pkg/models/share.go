@@ -0,0 +1,23 @@
+package models
+
+type SharePermission string
+
+const (
+	ShareRead SharePermission = "read"
+	ShareEdit SharePermission = "edit"
+)
+
+// ShareTodoRequest shares a todo with the user of the email, or changes the
+// permission of an existing share.
+type ShareTodoRequest struct {
+	Email      string          `json:"email" validate:"required,email,max=255"`
+	Permission SharePermission `json:"permission" validate:"required,oneof=read edit"`
+}
+
+type TodoShareResponse struct {
+	UserID     uint64          `json:"user_id"`
+	Name       string          `json:"name"`
+	Email      string          `json:"email"`
+	Permission SharePermission `json:"permission"`
+	CreatedAt  string          `json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+type TagRequest struct {
+	Name  string `json:"name" example:"work" validate:"required,max=50"`
+	Color string `json:"color" example:"#ff8800" validate:"omitempty,max=7,hexcolor"`
+}
+
+type TagResponse struct {
+	ID    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
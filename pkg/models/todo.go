@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Status string
 
 const (
@@ -9,18 +11,67 @@ const (
 )
 
 type TodoRequest struct {
-	Description string `json:"description" example:"Buy milk" validate:"min=6"`
+	Description    string     `json:"description" example:"Buy milk" validate:"min=6"`
+	DueAt          *time.Time `json:"due_at" example:"2024-07-01T18:00:00Z"`
+	RemindAt       *time.Time `json:"remind_at" example:"2024-07-01T17:00:00Z"`
+	OrganizationID *uint64    `json:"organization_id" example:"1"`
+	ProjectID      *uint64    `json:"project_id" example:"1"`
 }
 
+// TodoUpdateRequest replaces a todo. The status must be a state of the
+// workflow of the todo that can be reached from the current one. With
+// CompleteItems, completing the todo also completes its checklist.
 type TodoUpdateRequest struct {
-	Description string `json:"description" example:"Buy milk" validate:"min=6"`
-	Status      Status `json:"status" example:"pending"`
+	Description   string     `json:"description" example:"Buy milk" validate:"min=6"`
+	Status        Status     `json:"status" example:"pending" validate:"required,max=32"`
+	DueAt         *time.Time `json:"due_at" example:"2024-07-01T18:00:00Z"`
+	RemindAt      *time.Time `json:"remind_at" example:"2024-07-01T17:00:00Z"`
+	ProjectID     *uint64    `json:"project_id" example:"1"`
+	CompleteItems bool       `json:"complete_items"`
 }
 
+// TodoResponse has the tags the requesting user put on the todo. Progress is
+// only set for todos with a checklist.
 type TodoResponse struct {
-	ID          uint64 `json:"id"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID             uint64             `json:"id"`
+	Description    string             `json:"description"`
+	Status         string             `json:"status"`
+	UserID         uint64             `json:"user_id"`
+	OrganizationID *uint64            `json:"organization_id,omitempty"`
+	ProjectID      *uint64            `json:"project_id,omitempty"`
+	DueAt          string             `json:"due_at,omitempty"`
+	RemindAt       string             `json:"remind_at,omitempty"`
+	CompletedAt    string             `json:"completed_at,omitempty"`
+	Overdue        bool               `json:"overdue"`
+	Tags           []TagResponse      `json:"tags"`
+	Progress       *ChecklistProgress `json:"progress,omitempty"`
+	CreatedAt      string             `json:"created_at"`
+	UpdatedAt      string             `json:"updated_at"`
+}
+
+// TodoListQuery lists the private todos of the user, the todos of an
+// organization, or the todos shared with the user. Project 0 is the inbox.
+type TodoListQuery struct {
+	Organization  *uint64    `form:"organization_id"`
+	Shared        bool       `form:"shared" validate:"excluded_with=Organization"`
+	Project       *uint64    `form:"project_id"`
+	Status        Status     `form:"status" validate:"omitempty,max=32"`
+	CreatedAfter  *time.Time `form:"created_after"`
+	CreatedBefore *time.Time `form:"created_before"`
+	UpdatedAfter  *time.Time `form:"updated_after"`
+	UpdatedBefore *time.Time `form:"updated_before"`
+	DueAfter      *time.Time `form:"due_after"`
+	DueBefore     *time.Time `form:"due_before"`
+	Overdue       *bool      `form:"overdue"`
+	TagIDs        []uint64   `form:"tag_id"`
+	TagMode       string     `form:"tag_mode" validate:"omitempty,oneof=any all"`
+	Sort          string     `form:"sort" validate:"omitempty,oneof=created_at updated_at due_at completed_at"`
+	Order         string     `form:"order" validate:"omitempty,oneof=asc desc"`
+	Limit         int        `form:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor        string     `form:"cursor"`
+}
+
+type TodoListResponse struct {
+	Items      []TodoResponse `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
 }
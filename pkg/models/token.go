@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Scopes a personal access token can be granted. Sessions are not limited
+// by scopes.
+const (
+	ScopeTodoRead  = "todo:read"
+	ScopeTodoWrite = "todo:write"
+	ScopeUserRead  = "user:read"
+)
+
+type CreateTokenRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=todo:read todo:write user:read"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type TokenResponse struct {
+	ID         uint64   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type CreateTokenResponse struct {
+	TokenResponse
+	Token string `json:"token"`
+}
@@ -4,6 +4,7 @@ type UserResponse struct {
 	ID        uint64 `json:"id"`
 	Email     string `json:"email"`
 	Name      string `json:"name"`
+	Locale    string `json:"locale"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
@@ -12,3 +13,8 @@ type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password"`
 	NewPassword string `json:"new_password"`
 }
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required"`
+}
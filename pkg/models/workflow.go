@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WorkflowState is a status todos can have. Its category tells what the
+// status means: todos in a completed state count as done.
+type WorkflowState struct {
+	Name     string `json:"name" validate:"required,max=32"`
+	Category Status `json:"category" validate:"required,oneof=pending in_progress completed"`
+}
+
+type WorkflowTransition struct {
+	From string `json:"from" validate:"required,max=32"`
+	To   string `json:"to" validate:"required,max=32"`
+}
+
+// Workflow is the state machine of todo statuses. New todos start in the
+// first state and can only move along the transitions.
+type Workflow struct {
+	States      []WorkflowState      `json:"states" validate:"required,min=2,max=20,dive"`
+	Transitions []WorkflowTransition `json:"transitions" validate:"required,min=1,max=100,dive"`
+}
+
+// DefaultWorkflow is used unless the user or the project has their own.
+var DefaultWorkflow = Workflow{
+	States: []WorkflowState{
+		{Name: string(Pending), Category: Pending},
+		{Name: string(InProgress), Category: InProgress},
+		{Name: string(Completed), Category: Completed},
+	},
+	Transitions: []WorkflowTransition{
+		{From: string(Pending), To: string(InProgress)},
+		{From: string(Pending), To: string(Completed)},
+		{From: string(InProgress), To: string(Pending)},
+		{From: string(InProgress), To: string(Completed)},
+		{From: string(Completed), To: string(Pending)},
+	},
+}
+
+type WorkflowResponse struct {
+	Workflow
+	// Custom is false for the default workflow
+	Custom bool `json:"custom"`
+}
+
+func (w Workflow) Initial() WorkflowState {
+	return w.States[0]
+}
+
+func (w Workflow) State(name string) (WorkflowState, bool) {
+	for _, state := range w.States {
+		if state.Name == name {
+			return state, true
+		}
+	}
+
+	return WorkflowState{}, false
+}
+
+// Allows reports whether a todo may change from one status to another.
+// Keeping the status is always allowed.
+func (w Workflow) Allows(from, to string) bool {
+	if from == to {
+		return true
+	}
+
+	for _, transition := range w.Transitions {
+		if transition.From == from && transition.To == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check finds the mistakes the validator can't: duplicate states, transitions
+// between unknown states, and workflows todos can't start or finish in.
+func (w Workflow) Check() error {
+	seen := make(map[string]bool, len(w.States))
+	completed := false
+	for _, state := range w.States {
+		if seen[state.Name] {
+			return fmt.Errorf("state %q is defined twice", state.Name)
+		}
+		seen[state.Name] = true
+		completed = completed || state.Category == Completed
+	}
+
+	if w.Initial().Category != Pending {
+		return errors.New("the first state must be in the pending category")
+	}
+	if !completed {
+		return errors.New("at least one state must be in the completed category")
+	}
+
+	for _, transition := range w.Transitions {
+		if !seen[transition.From] || !seen[transition.To] {
+			return fmt.Errorf("transition from %q to %q has an unknown state", transition.From, transition.To)
+		}
+	}
+
+	return nil
+}